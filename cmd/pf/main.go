@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"pf/internal/cli"
@@ -9,9 +8,10 @@ import (
 
 func main() {
 	cmd := cli.NewRootCommand()
+	cmd.SetArgs(cli.CommandArgs(os.Args))
 	
 	if err := cmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		cli.PrintError(err)
+		os.Exit(cli.ExitCode(err))
 	}
 }
\ No newline at end of file
@@ -8,7 +8,9 @@ import (
 	"strings"
 
 	"filippo.io/age"
+	"filippo.io/age/agessh"
 	"filippo.io/age/armor"
+	"filippo.io/age/plugin"
 )
 
 // Encrypt encrypts data for the given age recipients
@@ -20,7 +22,7 @@ func Encrypt(data string, recipients []string) (string, error) {
 	// Parse recipients
 	var ageRecipients []age.Recipient
 	for _, recipient := range recipients {
-		r, err := age.ParseX25519Recipient(recipient)
+		r, err := ParseRecipient(recipient)
 		if err != nil {
 			return "", fmt.Errorf("invalid recipient %s: %w", recipient, err)
 		}
@@ -82,8 +84,26 @@ func GenerateKey() (*age.X25519Identity, error) {
 	return identity, nil
 }
 
-// ParseIdentity parses an age identity from string
+// ParseIdentity parses an age identity from string. Unencrypted OpenSSH
+// private keys in PEM form and plugin identities (AGE-PLUGIN-...) are
+// accepted as well.
 func ParseIdentity(s string) (age.Identity, error) {
+	if strings.HasPrefix(s, "-----BEGIN") {
+		identity, err := agessh.ParseIdentity([]byte(s))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSH identity: %w", err)
+		}
+		return identity, nil
+	}
+
+	if isPluginIdentity(s) {
+		identity, err := plugin.NewIdentity(s, pluginUI)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse plugin identity: %w", err)
+		}
+		return identity, nil
+	}
+
 	identity, err := age.ParseX25519Identity(s)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse identity: %w", err)
@@ -91,8 +111,26 @@ func ParseIdentity(s string) (age.Identity, error) {
 	return identity, nil
 }
 
-// ParseRecipient parses an age recipient from string
+// ParseRecipient parses an age recipient from string. SSH public keys
+// (ssh-ed25519, ssh-rsa) are accepted in authorized_keys format, and plugin
+// recipients (age1name1...) are handled by age-plugin-name found on PATH.
 func ParseRecipient(s string) (age.Recipient, error) {
+	if !strings.HasPrefix(s, "age1") {
+		recipient, err := agessh.ParseRecipient(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSH recipient: %w", err)
+		}
+		return recipient, nil
+	}
+
+	if isPluginRecipient(s) {
+		recipient, err := plugin.NewRecipient(s, pluginUI)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse plugin recipient: %w", err)
+		}
+		return recipient, nil
+	}
+
 	recipient, err := age.ParseX25519Recipient(s)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse recipient: %w", err)
@@ -119,28 +157,63 @@ func GenerateKeyPair() (*Key, error) {
 	}, nil
 }
 
-// LoadIdentityFile loads age identities from a file
-func LoadIdentityFile(path string) ([]age.Identity, error) {
+// LoadIdentityFile loads age identities from a file, which may be
+// passphrase-protected
+func LoadIdentityFile(path string) ([]*Identity, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read identity file: %w", err)
 	}
 
-	var identities []age.Identity
+	return LoadIdentities(data, path)
+}
+
+// LoadIdentities parses identities from key material in any of the formats
+// accepted in identity files. name identifies the material in prompts and
+// errors, such as the file or environment variable it came from.
+func LoadIdentities(data []byte, name string) ([]*Identity, error) {
+	data, err := decryptIdentityData(data, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if isSSHPrivateKey(data) {
+		identity, err := parseSSHIdentity(data, name)
+		if err != nil {
+			return nil, err
+		}
+		return []*Identity{identity}, nil
+	}
+
+	return ParseIdentities(data)
+}
+
+// ParseIdentities parses age identities from identity file contents
+func ParseIdentities(data []byte) ([]*Identity, error) {
+	var identities []*Identity
 	lines := strings.Split(string(data), "\n")
-	
+
+	// Recipient noted in a comment, as written by age-keygen and plugins
+	noted := ""
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if m := notedRecipient.FindStringSubmatch(line); m != nil {
+				noted = m[1]
+			}
 			continue
 		}
 
-		identity, err := ParseIdentity(line)
+		identity, err := parseIdentityLine(line, noted)
 		if err != nil {
 			// Skip invalid lines
 			continue
 		}
 		identities = append(identities, identity)
+		noted = ""
 	}
 
 	if len(identities) == 0 {
@@ -148,4 +221,4 @@ func LoadIdentityFile(path string) ([]age.Identity, error) {
 	}
 
 	return identities, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,95 @@
+package age
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"filippo.io/age"
+	"golang.org/x/crypto/ssh"
+)
+
+// Identity types
+const (
+	TypeX25519 = "x25519"
+	TypePlugin = "plugin"
+)
+
+// notedRecipient matches comments such as "# Public key: age1..." or
+// "#    Recipient: age1yubikey1..." preceding an identity
+var notedRecipient = regexp.MustCompile(`(?i)^#\s*(?:public key|recipient):\s*(age1\S+)`)
+
+// Identity is a loaded identity together with what is known about it
+type Identity struct {
+	age.Identity
+
+	// Type is x25519, plugin, or the SSH key type (ssh-ed25519, ssh-rsa)
+	Type string
+
+	// Recipient is the matching public key in .recipients format. It is
+	// empty when it cannot be derived, as for most plugin identities.
+	Recipient string
+
+	encode func() (string, error)
+}
+
+// Encode returns the identity in the text form accepted by ParseIdentity.
+// Encrypted SSH keys prompt for their passphrase and are returned decrypted.
+func (i *Identity) Encode() (string, error) {
+	return i.encode()
+}
+
+// AgeIdentities returns the age identities to decrypt with
+func AgeIdentities(identities []*Identity) []age.Identity {
+	result := make([]age.Identity, len(identities))
+	for i, identity := range identities {
+		result[i] = identity.Identity
+	}
+	return result
+}
+
+// SameRecipient reports whether two recipient strings designate the same
+// key, ignoring SSH key comments and options
+func SameRecipient(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if a == b {
+		return true
+	}
+
+	keyA, _, _, _, errA := ssh.ParseAuthorizedKey([]byte(a))
+	keyB, _, _, _, errB := ssh.ParseAuthorizedKey([]byte(b))
+	if errA != nil || errB != nil {
+		return false
+	}
+	return bytes.Equal(keyA.Marshal(), keyB.Marshal())
+}
+
+// parseIdentityLine parses an identity line. noted is a recipient found in
+// a preceding comment, used when it cannot be derived from the identity.
+func parseIdentityLine(line, noted string) (*Identity, error) {
+	parsed, err := ParseIdentity(line)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Identity: parsed,
+		encode:   func() (string, error) { return line, nil },
+	}
+
+	switch id := parsed.(type) {
+	case *age.X25519Identity:
+		identity.Type = TypeX25519
+		identity.Recipient = id.Recipient().String()
+	default:
+		if isPluginIdentity(line) {
+			identity.Type = TypePlugin
+			identity.Recipient = noted
+		} else {
+			return nil, fmt.Errorf("unsupported identity line")
+		}
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,127 @@
+package age
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// PassphraseEnv holds the identity file passphrase for non-interactive use
+const PassphraseEnv = "PF_AGE_PASSPHRASE"
+
+// IsEncrypted reports whether an identity file is itself age-encrypted
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("age-encryption.org/")) ||
+		bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header))
+}
+
+// EncryptIdentityFile encrypts identity file contents with a scrypt passphrase
+func EncryptIdentityFile(data, passphrase string) ([]byte, error) {
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid passphrase: %w", err)
+	}
+
+	var encrypted bytes.Buffer
+	armorWriter := armor.NewWriter(&encrypted)
+
+	w, err := age.Encrypt(armorWriter, recipient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encryptor: %w", err)
+	}
+	if _, err := io.WriteString(w, data); err != nil {
+		return nil, fmt.Errorf("failed to write data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close encryptor: %w", err)
+	}
+	if err := armorWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close armor writer: %w", err)
+	}
+
+	return encrypted.Bytes(), nil
+}
+
+// DecryptIdentityFile decrypts a passphrase-protected identity file
+func DecryptIdentityFile(data []byte, passphrase string) ([]byte, error) {
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid passphrase: %w", err)
+	}
+
+	var src io.Reader = bytes.NewReader(data)
+	if !bytes.HasPrefix(data, []byte("age-encryption.org/")) {
+		src = armor.NewReader(bytes.NewReader(bytes.TrimSpace(data)))
+	}
+
+	r, err := age.Decrypt(src, identity)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, fmt.Errorf("incorrect passphrase")
+		}
+		return nil, fmt.Errorf("failed to decrypt identity file: %w", err)
+	}
+
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identity file: %w", err)
+	}
+	return plaintext, nil
+}
+
+// ReadIdentityFile reads an identity file, decrypting it if it is
+// passphrase-protected. The passphrase comes from PF_AGE_PASSPHRASE or a
+// terminal prompt.
+func ReadIdentityFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identity file: %w", err)
+	}
+
+	return decryptIdentityData(data, path)
+}
+
+// decryptIdentityData decrypts identity data if it is passphrase-protected.
+// name identifies the data in the prompt.
+func decryptIdentityData(data []byte, name string) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	passphrase, ok := os.LookupEnv(PassphraseEnv)
+	if !ok {
+		p, err := ReadPassphrase(fmt.Sprintf("Enter passphrase for %s: ", name))
+		if err != nil {
+			return nil, fmt.Errorf("identity file is passphrase-protected (set %s or use pf agent): %w", PassphraseEnv, err)
+		}
+		passphrase = p
+	}
+
+	return DecryptIdentityFile(data, passphrase)
+}
+
+// ReadPassphrase prompts for a passphrase on the controlling terminal
+func ReadPassphrase(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("no terminal available")
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, prompt)
+	passphrase, err := terminal.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(tty)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+
+	return strings.TrimRight(string(passphrase), "\r\n"), nil
+}
@@ -0,0 +1,67 @@
+package age
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"filippo.io/age/plugin"
+)
+
+// isPluginRecipient reports whether s is a plugin recipient ("age1name1...")
+// rather than a native X25519 one
+func isPluginRecipient(s string) bool {
+	return strings.HasPrefix(s, "age1") && strings.Count(s, "1") > 1
+}
+
+// isPluginIdentity reports whether s is a plugin identity ("AGE-PLUGIN-NAME-1...")
+func isPluginIdentity(s string) bool {
+	return strings.HasPrefix(s, "AGE-PLUGIN-")
+}
+
+// pluginUI lets age-plugin-* binaries interact with the user on the terminal
+var pluginUI = &plugin.ClientUI{
+	DisplayMessage: func(name, message string) error {
+		fmt.Fprintf(os.Stderr, "age-plugin-%s: %s\n", name, message)
+		return nil
+	},
+	RequestValue: func(name, prompt string, secret bool) (string, error) {
+		prompt = fmt.Sprintf("age-plugin-%s: %s ", name, prompt)
+		if secret {
+			return ReadPassphrase(prompt)
+		}
+		return readLine(prompt)
+	},
+	Confirm: func(name, prompt, yes, no string) (bool, error) {
+		choices := fmt.Sprintf("[%s]", yes)
+		if no != "" {
+			choices = fmt.Sprintf("[%s/%s]", yes, no)
+		}
+		answer, err := readLine(fmt.Sprintf("age-plugin-%s: %s %s ", name, prompt, choices))
+		if err != nil {
+			return false, err
+		}
+		return no == "" || strings.EqualFold(answer, yes), nil
+	},
+	WaitTimer: func(name string) {
+		fmt.Fprintf(os.Stderr, "Waiting for age-plugin-%s...\n", name)
+	},
+}
+
+// readLine prompts for a line of input on the controlling terminal
+func readLine(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("no terminal available")
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, prompt)
+	line, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,290 @@
+package age
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/plugin"
+)
+
+// fakePlugin is the name of the plugin the test binary plays when it is run
+// as age-plugin-pftest
+const fakePlugin = "pftest"
+
+func TestMain(m *testing.M) {
+	if filepath.Base(os.Args[0]) == "age-plugin-"+fakePlugin {
+		if err := runFakePlugin(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runFakePlugin speaks the plugin side of the age plugin protocol. The fake
+// "wraps" the file key by sending it in the clear, in a stanza naming the
+// recipient's data, and unwraps stanzas naming an identity's data, which is
+// enough to exercise the client side.
+func runFakePlugin(args []string, in io.Reader, out io.Writer) error {
+	r := bufio.NewReader(in)
+	switch {
+	case len(args) == 1 && args[0] == "--age-plugin=recipient-v1":
+		return fakeRecipient(r, out)
+	case len(args) == 1 && args[0] == "--age-plugin=identity-v1":
+		return fakeIdentity(r, out)
+	default:
+		return fmt.Errorf("unexpected arguments %q", args)
+	}
+}
+
+func fakeRecipient(r *bufio.Reader, out io.Writer) error {
+	var recipients [][]byte
+	var fileKey []byte
+	for {
+		s, err := readTestStanza(r)
+		if err != nil {
+			return err
+		}
+		if s.typ == "done" {
+			break
+		}
+		switch s.typ {
+		case "add-recipient":
+			_, data, err := plugin.ParseRecipient(s.args[0])
+			if err != nil {
+				return err
+			}
+			recipients = append(recipients, data)
+		case "wrap-file-key":
+			fileKey = s.body
+		}
+	}
+
+	for _, data := range recipients {
+		writeTestStanza(out, []string{"recipient-stanza", "0", fakePlugin, encodeArg(data)}, fileKey)
+		if s, err := readTestStanza(r); err != nil || s.typ != "ok" {
+			return fmt.Errorf("expected ok: %v", err)
+		}
+	}
+	writeTestStanza(out, []string{"done"}, nil)
+	return nil
+}
+
+func fakeIdentity(r *bufio.Reader, out io.Writer) error {
+	identities := make(map[string]bool)
+	var stanzas []testStanza
+	for {
+		s, err := readTestStanza(r)
+		if err != nil {
+			return err
+		}
+		if s.typ == "done" {
+			break
+		}
+		switch s.typ {
+		case "add-identity":
+			_, data, err := plugin.ParseIdentity(s.args[0])
+			if err != nil {
+				return err
+			}
+			identities[encodeArg(data)] = true
+		case "recipient-stanza":
+			stanzas = append(stanzas, s)
+		}
+	}
+
+	// A message for the user, as hardware plugins send
+	writeTestStanza(out, []string{"msg"}, []byte("touch your key"))
+	if s, err := readTestStanza(r); err != nil || s.typ != "ok" {
+		return fmt.Errorf("expected ok: %v", err)
+	}
+
+	for _, s := range stanzas {
+		// recipient-stanza <file index> <type> <args...>
+		if len(s.args) == 3 && s.args[1] == fakePlugin && identities[s.args[2]] {
+			writeTestStanza(out, []string{"file-key", s.args[0]}, s.body)
+			if s, err := readTestStanza(r); err != nil || s.typ != "ok" {
+				return fmt.Errorf("expected ok: %v", err)
+			}
+			break
+		}
+	}
+	writeTestStanza(out, []string{"done"}, nil)
+	return nil
+}
+
+type testStanza struct {
+	typ  string
+	args []string
+	body []byte
+}
+
+// readTestStanza reads "-> type args..." and a base64 body whose last line
+// is shorter than 64 columns
+func readTestStanza(r *bufio.Reader) (testStanza, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return testStanza{}, err
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, "-> "))
+	if !strings.HasPrefix(line, "-> ") || len(fields) == 0 {
+		return testStanza{}, fmt.Errorf("malformed stanza %q", line)
+	}
+
+	s := testStanza{typ: fields[0], args: fields[1:]}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return testStanza{}, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		data, err := base64.RawStdEncoding.DecodeString(line)
+		if err != nil {
+			return testStanza{}, fmt.Errorf("malformed stanza body: %w", err)
+		}
+		s.body = append(s.body, data...)
+		if len(line) < 64 {
+			return s, nil
+		}
+	}
+}
+
+func writeTestStanza(w io.Writer, args []string, body []byte) {
+	fmt.Fprintf(w, "-> %s\n", strings.Join(args, " "))
+	encoded := base64.RawStdEncoding.EncodeToString(body)
+	for len(encoded) >= 64 {
+		fmt.Fprintln(w, encoded[:64])
+		encoded = encoded[64:]
+	}
+	fmt.Fprintln(w, encoded)
+}
+
+func encodeArg(data []byte) string {
+	return base64.RawStdEncoding.EncodeToString(data)
+}
+
+// installFakePlugin puts the test binary on PATH as age-plugin-pftest
+func installFakePlugin(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake plugin is a symlink")
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Symlink(self, filepath.Join(dir, "age-plugin-"+fakePlugin)); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func parseTestIdentities(t *testing.T, lines ...string) []age.Identity {
+	t.Helper()
+	identities, err := ParseIdentities([]byte(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return AgeIdentities(identities)
+}
+
+func TestPluginRoundTrip(t *testing.T) {
+	installFakePlugin(t)
+
+	recipient := plugin.EncodeRecipient(fakePlugin, []byte("key-a"))
+	identity := plugin.EncodeIdentity(fakePlugin, []byte("key-a"))
+
+	encrypted, err := Encrypt("s3cret\nusername: deploy", []string{recipient})
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	decrypted, err := Decrypt(encrypted, parseTestIdentities(t, identity))
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if decrypted != "s3cret\nusername: deploy" {
+		t.Errorf("Decrypt = %q, want the encrypted secret", decrypted)
+	}
+}
+
+func TestPluginWithNativeRecipient(t *testing.T) {
+	installFakePlugin(t)
+
+	key, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipients := []string{plugin.EncodeRecipient(fakePlugin, []byte("key-a")), key.Recipient}
+
+	encrypted, err := Encrypt("s3cret", recipients)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	for name, identity := range map[string]string{
+		"plugin": plugin.EncodeIdentity(fakePlugin, []byte("key-a")),
+		"x25519": key.Identity,
+	} {
+		decrypted, err := Decrypt(encrypted, parseTestIdentities(t, identity))
+		if err != nil {
+			t.Errorf("Decrypt with %s identity: %v", name, err)
+		} else if decrypted != "s3cret" {
+			t.Errorf("Decrypt with %s identity = %q, want %q", name, decrypted, "s3cret")
+		}
+	}
+}
+
+func TestPluginWrongIdentity(t *testing.T) {
+	installFakePlugin(t)
+
+	encrypted, err := Encrypt("s3cret", []string{plugin.EncodeRecipient(fakePlugin, []byte("key-a"))})
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	identities := parseTestIdentities(t, plugin.EncodeIdentity(fakePlugin, []byte("key-b")))
+	if _, err := Decrypt(encrypted, identities); err == nil {
+		t.Error("Decrypt with another plugin identity succeeded")
+	}
+}
+
+func TestPluginMissingBinary(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := Encrypt("s3cret", []string{plugin.EncodeRecipient("pfmissing", []byte("key-a"))})
+	if err == nil || !strings.Contains(err.Error(), "pfmissing") {
+		t.Errorf("Encrypt without the plugin binary: err = %v, want an error naming the plugin", err)
+	}
+}
+
+func TestPluginIdentityFile(t *testing.T) {
+	recipient := plugin.EncodeRecipient(fakePlugin, []byte("key-a"))
+	data := fmt.Sprintf("# created: 2026-10-18\n# recipient: %s\n%s\n",
+		recipient, plugin.EncodeIdentity(fakePlugin, []byte("key-a")))
+
+	identities, err := ParseIdentities([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseIdentities: %v", err)
+	}
+	if len(identities) != 1 {
+		t.Fatalf("ParseIdentities returned %d identities, want 1", len(identities))
+	}
+	if identities[0].Type != TypePlugin {
+		t.Errorf("Type = %q, want %q", identities[0].Type, TypePlugin)
+	}
+	if identities[0].Recipient != recipient {
+		t.Errorf("Recipient = %q, want the recipient noted in the file", identities[0].Recipient)
+	}
+}
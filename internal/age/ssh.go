@@ -0,0 +1,94 @@
+package age
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	"golang.org/x/crypto/ssh"
+)
+
+// isSSHPrivateKey reports whether identity file contents are a PEM-encoded
+// SSH private key rather than age identity lines
+func isSSHPrivateKey(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")) && !IsEncrypted(data)
+}
+
+// parseSSHIdentity parses an OpenSSH private key. Encrypted keys prompt for
+// their passphrase only when they are actually needed to decrypt.
+func parseSSHIdentity(pemBytes []byte, path string) (*Identity, error) {
+	parsed, err := agessh.ParseIdentity(pemBytes)
+	if err == nil {
+		signer, err := ssh.ParsePrivateKey(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSH key %s: %w", path, err)
+		}
+		return newSSHIdentity(parsed, signer.PublicKey(), func() (string, error) {
+			return string(pemBytes), nil
+		}), nil
+	}
+
+	var missing *ssh.PassphraseMissingError
+	if !errors.As(err, &missing) {
+		return nil, fmt.Errorf("failed to parse SSH key %s: %w", path, err)
+	}
+
+	// Older key formats don't embed the public key, look for it next to the file
+	pubKey := missing.PublicKey
+	if pubKey == nil {
+		pubData, err := os.ReadFile(path + ".pub")
+		if err != nil {
+			return nil, fmt.Errorf("encrypted SSH key %s needs its public key in %s.pub", path, path)
+		}
+		pubKey, _, _, _, err = ssh.ParseAuthorizedKey(pubData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s.pub: %w", path, err)
+		}
+	}
+
+	passphrase := func() ([]byte, error) {
+		if p, ok := os.LookupEnv(PassphraseEnv); ok {
+			return []byte(p), nil
+		}
+		p, err := ReadPassphrase(fmt.Sprintf("Enter passphrase for SSH key %s: ", path))
+		if err != nil {
+			return nil, fmt.Errorf("SSH key is passphrase-protected (set %s or use pf agent): %w", PassphraseEnv, err)
+		}
+		return []byte(p), nil
+	}
+
+	encrypted, err := agessh.NewEncryptedSSHIdentity(pubKey, pemBytes, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SSH key %s: %w", path, err)
+	}
+
+	return newSSHIdentity(encrypted, pubKey, func() (string, error) {
+		p, err := passphrase()
+		if err != nil {
+			return "", err
+		}
+		key, err := ssh.ParseRawPrivateKeyWithPassphrase(pemBytes, p)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt SSH key %s: %w", path, err)
+		}
+		block, err := ssh.MarshalPrivateKey(key, "")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode SSH key %s: %w", path, err)
+		}
+		return string(pem.EncodeToMemory(block)), nil
+	}), nil
+}
+
+func newSSHIdentity(identity age.Identity, pubKey ssh.PublicKey, encode func() (string, error)) *Identity {
+	return &Identity{
+		Identity:  identity,
+		Type:      pubKey.Type(),
+		Recipient: strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pubKey))),
+		encode:    encode,
+	}
+}
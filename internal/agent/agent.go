@@ -0,0 +1,224 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"filippo.io/age"
+
+	pfage "pf/internal/age"
+)
+
+// Operations understood by the agent
+const (
+	OpAdd    = "add"
+	OpUnwrap = "unwrap"
+	OpLock   = "lock"
+	OpStatus = "status"
+	OpStop   = "stop"
+)
+
+// ErrLocked is returned when the agent holds no identities
+var ErrLocked = errors.New("agent is locked")
+
+// Stanza is the wire form of an age recipient stanza
+type Stanza struct {
+	Type string   `json:"type"`
+	Args []string `json:"args"`
+	Body []byte   `json:"body"`
+}
+
+// Request is a single request sent to the agent
+type Request struct {
+	Op         string   `json:"op"`
+	Identities []string `json:"identities,omitempty"`
+	Stanzas    []Stanza `json:"stanzas,omitempty"`
+}
+
+// Response is the agent's reply to a Request
+type Response struct {
+	Error      string        `json:"error,omitempty"`
+	NoMatch    bool          `json:"no_match,omitempty"`
+	FileKey    []byte        `json:"file_key,omitempty"`
+	Identities int           `json:"identities"`
+	Timeout    time.Duration `json:"timeout"`
+	PID        int           `json:"pid"`
+}
+
+// SocketPath returns the agent socket path
+func SocketPath() string {
+	if path := os.Getenv("PF_AGENT_SOCK"); path != "" {
+		return path
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "pf-agent.sock")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".pf", "agent.sock")
+}
+
+// Server holds decrypted identities in memory and unwraps file keys for clients
+type Server struct {
+	mu         sync.Mutex
+	identities []age.Identity
+	timeout    time.Duration
+	timer      *time.Timer
+	listener   net.Listener
+}
+
+// NewServer creates an agent that forgets its identities after being idle for timeout.
+// A zero timeout keeps identities until the agent is locked or stopped.
+func NewServer(timeout time.Duration) *Server {
+	return &Server{timeout: timeout}
+}
+
+// ErrSocketInUse is returned by Listen when another process serves the socket
+var ErrSocketInUse = errors.New("socket already in use")
+
+// Listen creates a Unix socket only the current user can connect to,
+// replacing a stale one
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w: %s", ErrSocketInUse, path)
+		}
+		os.Remove(path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to secure socket: %w", err)
+	}
+
+	return listener, nil
+}
+
+// Serve accepts connections until the listener is closed or the agent is stopped
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Lock forgets all identities
+func (s *Server) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lockLocked()
+}
+
+func (s *Server) lockLocked() {
+	s.identities = nil
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	if err := checkPeer(conn); err != nil {
+		json.NewEncoder(conn).Encode(Response{Error: err.Error()})
+		return
+	}
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(Response{Error: "invalid request"})
+		return
+	}
+
+	resp := s.dispatch(&req)
+	json.NewEncoder(conn).Encode(resp)
+
+	if req.Op == OpStop && resp.Error == "" {
+		s.mu.Lock()
+		s.lockLocked()
+		if s.listener != nil {
+			s.listener.Close()
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *Server) dispatch(req *Request) Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.Op {
+	case OpAdd:
+		for _, line := range req.Identities {
+			identity, err := pfage.ParseIdentity(line)
+			if err != nil {
+				return Response{Error: err.Error()}
+			}
+			s.identities = append(s.identities, identity)
+		}
+		s.touchLocked()
+	case OpUnwrap:
+		if len(s.identities) == 0 {
+			return Response{Error: ErrLocked.Error()}
+		}
+		stanzas := make([]*age.Stanza, len(req.Stanzas))
+		for i, st := range req.Stanzas {
+			stanzas[i] = &age.Stanza{Type: st.Type, Args: st.Args, Body: st.Body}
+		}
+		s.touchLocked()
+		for _, identity := range s.identities {
+			fileKey, err := identity.Unwrap(stanzas)
+			if errors.Is(err, age.ErrIncorrectIdentity) {
+				continue
+			}
+			if err != nil {
+				return Response{Error: err.Error()}
+			}
+			return Response{FileKey: fileKey}
+		}
+		return Response{NoMatch: true}
+	case OpLock:
+		s.lockLocked()
+	case OpStatus, OpStop:
+	default:
+		return Response{Error: fmt.Sprintf("unknown operation: %s", req.Op)}
+	}
+
+	return Response{Identities: len(s.identities), Timeout: s.timeout, PID: os.Getpid()}
+}
+
+// touchLocked restarts the idle timer
+func (s *Server) touchLocked() {
+	if s.timeout <= 0 || len(s.identities) == 0 {
+		return
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(s.timeout, s.Lock)
+}
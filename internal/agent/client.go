@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"filippo.io/age"
+)
+
+// Client talks to a running agent
+type Client struct {
+	path string
+}
+
+// Dial connects to the agent listening on SocketPath
+func Dial() (*Client, error) {
+	c := &Client{path: SocketPath()}
+	if _, err := c.Status(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Status returns the state of the agent
+func (c *Client) Status() (*Response, error) {
+	return c.call(&Request{Op: OpStatus})
+}
+
+// Add sends identities to the agent
+func (c *Client) Add(identities []string) error {
+	_, err := c.call(&Request{Op: OpAdd, Identities: identities})
+	return err
+}
+
+// Lock makes the agent forget its identities
+func (c *Client) Lock() error {
+	_, err := c.call(&Request{Op: OpLock})
+	return err
+}
+
+// Stop shuts the agent down
+func (c *Client) Stop() error {
+	_, err := c.call(&Request{Op: OpStop})
+	return err
+}
+
+// Identity returns an age identity that unwraps file keys through the agent
+func (c *Client) Identity() age.Identity {
+	return &identity{client: c}
+}
+
+func (c *Client) call(req *Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", c.path, time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to agent: %w", err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send request to agent: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read agent response: %w", err)
+	}
+	if resp.Error != "" {
+		if resp.Error == ErrLocked.Error() {
+			return nil, ErrLocked
+		}
+		return nil, errors.New(resp.Error)
+	}
+
+	return &resp, nil
+}
+
+// identity forwards stanzas to the agent, so private keys never leave it
+type identity struct {
+	client *Client
+}
+
+func (i *identity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	req := &Request{Op: OpUnwrap}
+	for _, s := range stanzas {
+		req.Stanzas = append(req.Stanzas, Stanza{Type: s.Type, Args: s.Args, Body: s.Body})
+	}
+
+	resp, err := i.client.call(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.NoMatch {
+		return nil, age.ErrIncorrectIdentity
+	}
+
+	return resp.FileKey, nil
+}
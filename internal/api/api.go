@@ -0,0 +1,344 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pf/internal/audit"
+	"pf/internal/config"
+	"pf/internal/otp"
+	"pf/internal/store"
+)
+
+// maxBodySize bounds request bodies
+const maxBodySize = 1 << 20
+
+// Server serves the pf HTTP/JSON API for a single store:
+//
+//	GET /v1/keys              list keys
+//	GET /v1/secrets/<key>     get a password, ?version=N for an older version
+//	PUT /v1/secrets/<key>     store a new version
+//	GET /v1/history/<key>     list versions, without passwords
+//	GET /v1/otp/<key>         generate a TOTP code from the entry's otpauth:// URI
+//
+// Clients authenticate with "Authorization: Bearer <token>".
+type Server struct {
+	store   *store.Store
+	clients []config.APIClient
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type keysResponse struct {
+	Keys []string `json:"keys"`
+}
+
+type secretResponse struct {
+	Key      string `json:"key"`
+	Version  int    `json:"version,omitempty"`
+	Password string `json:"password"`
+}
+
+type putRequest struct {
+	Password string `json:"password"`
+	Message  string `json:"message,omitempty"`
+}
+
+type otpResponse struct {
+	Key       string `json:"key"`
+	Code      string `json:"code"`
+	Period    int    `json:"period"`
+	Remaining int    `json:"remaining"`
+}
+
+type historyResponse struct {
+	Key      string        `json:"key"`
+	Versions []versionInfo `json:"versions"`
+}
+
+type versionInfo struct {
+	Version   int    `json:"version"`
+	Timestamp string `json:"timestamp"`
+	Author    string `json:"author,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// NewServer creates an API server for s, accepting the given clients
+func NewServer(s *store.Store, clients []config.APIClient) *Server {
+	return &Server{store: s, clients: clients}
+}
+
+// GenerateToken returns a new random client token
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "pf_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hash of a token as stored in the configuration
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	client := s.authenticate(r)
+	if client == nil {
+		s.store.Audit(audit.EventAccess, "", fmt.Sprintf("API request denied, invalid or missing token: %s %q", r.Method, r.URL.Path))
+		writeError(w, http.StatusUnauthorized, "invalid or missing token")
+		return
+	}
+
+	// Audit events and new versions are attributed to the client
+	st := s.store.WithAuthor(client.Name)
+
+	path := r.URL.Path
+	switch {
+	case path == "/v1/keys":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r, st, "")
+			return
+		}
+		s.handleList(w, st, client)
+
+	case strings.HasPrefix(path, "/v1/secrets/"):
+		key, ok := s.authorizeKey(w, st, client, strings.TrimPrefix(path, "/v1/secrets/"))
+		if !ok {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			s.handleGet(w, r, st, key)
+		case http.MethodPut:
+			s.handlePut(w, r, st, key)
+		default:
+			methodNotAllowed(w, r, st, key)
+		}
+
+	case strings.HasPrefix(path, "/v1/history/"):
+		key, ok := s.authorizeKey(w, st, client, strings.TrimPrefix(path, "/v1/history/"))
+		if !ok {
+			return
+		}
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r, st, key)
+			return
+		}
+		s.handleHistory(w, st, key)
+
+	case strings.HasPrefix(path, "/v1/otp/"):
+		key, ok := s.authorizeKey(w, st, client, strings.TrimPrefix(path, "/v1/otp/"))
+		if !ok {
+			return
+		}
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r, st, key)
+			return
+		}
+		s.handleOTP(w, st, key)
+
+	default:
+		st.Audit(audit.EventAccess, "", fmt.Sprintf("API request denied, unknown path: %s %q", r.Method, r.URL.Path))
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) handleList(w http.ResponseWriter, st *store.Store, client *config.APIClient) {
+	st.Audit(audit.EventAccess, "", "API list")
+
+	keys, err := st.List()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	allowed := []string{}
+	for _, key := range keys {
+		if inScope(client, key) {
+			allowed = append(allowed, key)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, keysResponse{Keys: allowed})
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request, st *store.Store, key string) {
+	version := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "invalid version")
+			return
+		}
+		version = n
+	}
+
+	password, err := st.Get(key, version)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, secretResponse{Key: key, Version: version, Password: password})
+}
+
+func (s *Server) handlePut(w http.ResponseWriter, r *http.Request, st *store.Store, key string) {
+	var req putRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Password == "" {
+		writeError(w, http.StatusBadRequest, "password is required")
+		return
+	}
+
+	if err := st.Put(key, req.Password, req.Message); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, st *store.Store, key string) {
+	st.Audit(audit.EventAccess, key, "API history")
+
+	versions, err := st.GetHistory(key, 0)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	resp := historyResponse{Key: key, Versions: []versionInfo{}}
+	for _, v := range versions {
+		resp.Versions = append(resp.Versions, versionInfo{
+			Version:   v.Version,
+			Timestamp: time.Unix(v.Timestamp, 0).UTC().Format(time.RFC3339),
+			Author:    v.Author,
+			Message:   v.Message,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleOTP(w http.ResponseWriter, st *store.Store, key string) {
+	secret, err := st.Get(key, 0)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	otpKey, err := otp.Find(secret)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	now := time.Now()
+	writeJSON(w, http.StatusOK, otpResponse{
+		Key:       key,
+		Code:      otpKey.Code(now),
+		Period:    int(otpKey.Period / time.Second),
+		Remaining: int(otpKey.Remaining(now) / time.Second),
+	})
+}
+
+// authenticate returns the client matching the request's bearer token
+func (s *Server) authenticate(r *http.Request) *config.APIClient {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil
+	}
+
+	hash := HashToken(token)
+	for i := range s.clients {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(s.clients[i].TokenHash)) == 1 {
+			return &s.clients[i]
+		}
+	}
+	return nil
+}
+
+// authorizeKey validates a key from the request path and checks it is in
+// the client's scopes, writing the error response if not
+func (s *Server) authorizeKey(w http.ResponseWriter, st *store.Store, client *config.APIClient, key string) (string, bool) {
+	if !validKey(key) {
+		st.Audit(audit.EventAccess, "", fmt.Sprintf("API request denied, invalid key %q", key))
+		writeError(w, http.StatusBadRequest, "invalid key")
+		return "", false
+	}
+	if !inScope(client, key) {
+		st.Audit(audit.EventAccess, key, "API access denied")
+		writeError(w, http.StatusForbidden, "key outside of client scopes")
+		return "", false
+	}
+	return key, true
+}
+
+// validKey rejects keys that would resolve outside of the store
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." || strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	return true
+}
+
+// inScope reports whether key is one of the client's scopes or below one.
+// Scopes are whole path segments, db covers db/prod but not dbadmin/root.
+func inScope(client *config.APIClient, key string) bool {
+	if len(client.Scopes) == 0 {
+		return true
+	}
+	for _, scope := range client.Scopes {
+		scope = strings.TrimSuffix(scope, "/")
+		if key == scope || strings.HasPrefix(key, scope+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// methodNotAllowed audits and rejects a request with an unsupported method
+func methodNotAllowed(w http.ResponseWriter, r *http.Request, st *store.Store, key string) {
+	st.Audit(audit.EventAccess, key, fmt.Sprintf("API request denied, method not allowed: %s %q", r.Method, r.URL.Path))
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, store.ErrNotFound) || errors.Is(err, store.ErrVersionOutOfRange) {
+		status = http.StatusNotFound
+	}
+	writeError(w, status, err.Error())
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -32,15 +32,22 @@ func New(path string) *Logger {
 	}
 }
 
-// Log writes an audit event
+// Log writes an audit event for the current user
 func (l *Logger) Log(event, key, details string) error {
+	return l.LogAs("", event, key, details)
+}
+
+// LogAs writes an audit event for user, the current user when empty
+func (l *Logger) LogAs(user, event, key, details string) error {
 	if !l.enabled {
 		return nil
 	}
 
 	// Format log entry
 	timestamp := time.Now().Format("2006-01-02T15:04:05Z07:00")
-	user := os.Getenv("USER")
+	if user == "" {
+		user = os.Getenv("USER")
+	}
 	if user == "" {
 		user = "unknown"
 	}
@@ -0,0 +1,239 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// indexMagic identifies a compact index built by BuildIndex
+const indexMagic = "PFHIBP1\n"
+
+// hashSize is the size of a raw SHA-1 digest
+const hashSize = sha1.Size
+
+// Dataset is a local copy of the Have I Been Pwned password hashes
+type Dataset interface {
+	// Contains reports whether the SHA-1 of secret appears in the dataset
+	Contains(secret string) (bool, error)
+	Close() error
+}
+
+// Open opens a HIBP dataset, either the SHA-1 "ordered by hash" text file
+// or a compact index produced by BuildIndex
+func Open(path string) (Dataset, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat dataset: %w", err)
+	}
+
+	header := make([]byte, len(indexMagic))
+	n, _ := file.ReadAt(header, 0)
+	if n == len(indexMagic) && string(header) == indexMagic {
+		size := info.Size() - int64(len(indexMagic))
+		if size%hashSize != 0 {
+			file.Close()
+			return nil, fmt.Errorf("corrupt dataset index: %s", path)
+		}
+		return &indexDataset{file: file, count: size / hashSize}, nil
+	}
+
+	return &textDataset{file: file, size: info.Size()}, nil
+}
+
+// BuildIndex converts a sorted HIBP text file into a compact index of raw
+// SHA-1 digests and returns the number of hashes written
+func BuildIndex(src io.Reader, dst io.Writer) (int64, error) {
+	w := bufio.NewWriter(dst)
+	if _, err := w.WriteString(indexMagic); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	var prev []byte
+	scanner := bufio.NewScanner(src)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		hash, err := parseHash(line)
+		if err != nil {
+			return count, fmt.Errorf("line %d: %w", count+1, err)
+		}
+		if prev != nil && bytes.Compare(prev, hash) >= 0 {
+			return count, fmt.Errorf("line %d: dataset is not sorted by hash", count+1)
+		}
+
+		if _, err := w.Write(hash); err != nil {
+			return count, err
+		}
+		prev = hash
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return count, fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	return count, w.Flush()
+}
+
+// textDataset binary-searches the "HASH:COUNT" lines of the HIBP text file
+type textDataset struct {
+	file *os.File
+	size int64
+}
+
+func (d *textDataset) Contains(secret string) (bool, error) {
+	target := hashSecret(secret)
+
+	lo, hi := int64(0), d.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, err := d.lineStart(mid, lo)
+		if err != nil {
+			return false, err
+		}
+		if start >= hi {
+			// No line starts in the upper half, fall back to a short scan
+			break
+		}
+
+		line, next, err := d.readLine(start)
+		if err != nil {
+			return false, err
+		}
+		hash, err := parseHash(line)
+		if err != nil {
+			return false, err
+		}
+
+		switch cmp := bytes.Compare(hash, target); {
+		case cmp == 0:
+			return true, nil
+		case cmp < 0:
+			lo = next
+		default:
+			hi = start
+		}
+	}
+
+	for pos := lo; pos < hi; {
+		line, next, err := d.readLine(pos)
+		if err != nil {
+			return false, err
+		}
+		hash, err := parseHash(line)
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(hash, target) {
+			return true, nil
+		}
+		pos = next
+	}
+
+	return false, nil
+}
+
+func (d *textDataset) Close() error {
+	return d.file.Close()
+}
+
+// lineStart returns the offset of the first line starting at or after off
+func (d *textDataset) lineStart(off, lo int64) (int64, error) {
+	if off <= lo {
+		return lo, nil
+	}
+	_, next, err := d.readLine(off - 1)
+	return next, err
+}
+
+// readLine reads the line starting at off and returns it along with the
+// offset of the following line
+func (d *textDataset) readLine(off int64) (string, int64, error) {
+	var line []byte
+	buf := make([]byte, 128)
+	for pos := off; pos < d.size; {
+		n, err := d.file.ReadAt(buf, pos)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			line = append(line, buf[:i]...)
+			return strings.TrimSpace(string(line)), pos + int64(i) + 1, nil
+		}
+		line = append(line, buf[:n]...)
+		pos += int64(n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to read dataset: %w", err)
+		}
+	}
+	return strings.TrimSpace(string(line)), d.size, nil
+}
+
+// indexDataset binary-searches fixed-size records of a compact index
+type indexDataset struct {
+	file  *os.File
+	count int64
+}
+
+func (d *indexDataset) Contains(secret string) (bool, error) {
+	target := hashSecret(secret)
+	record := make([]byte, hashSize)
+
+	lo, hi := int64(0), d.count
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if _, err := d.file.ReadAt(record, int64(len(indexMagic))+mid*hashSize); err != nil {
+			return false, fmt.Errorf("failed to read dataset index: %w", err)
+		}
+
+		switch cmp := bytes.Compare(record, target); {
+		case cmp == 0:
+			return true, nil
+		case cmp < 0:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return false, nil
+}
+
+func (d *indexDataset) Close() error {
+	return d.file.Close()
+}
+
+func hashSecret(secret string) []byte {
+	sum := sha1.Sum([]byte(secret))
+	return sum[:]
+}
+
+// parseHash decodes the hex SHA-1 at the beginning of a dataset line
+func parseHash(line string) ([]byte, error) {
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		line = line[:i]
+	}
+	if len(line) != hex.EncodedLen(hashSize) {
+		return nil, fmt.Errorf("invalid hash line: %q", line)
+	}
+	hash, err := hex.DecodeString(line)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hash line: %q", line)
+	}
+	return hash, nil
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +10,8 @@ import (
 
 	"pf/internal/age"
 	"pf/internal/config"
+	"pf/internal/keyring"
+	"pf/internal/qrterm"
 )
 
 // NewAgeCommand creates the age command
@@ -23,6 +26,9 @@ func NewAgeCommand() *cobra.Command {
 		newAgeGenerateCommand(),
 		newAgeExportCommand(),
 		newAgeImportCommand(),
+		newAgePasswdCommand(),
+		newAgeRotateCommand(),
+		newAgeListCommand(),
 	)
 
 	return cmd
@@ -35,17 +41,26 @@ func newAgeGenerateCommand() *cobra.Command {
 		RunE:  runAgeGenerate,
 	}
 
-	cmd.Flags().String("output", "", "Output file for private key")
+	cmd.Flags().String("out-file", "", "Output file for private key")
+	cmd.Flags().Bool("passphrase", false, "Protect the key file with a passphrase")
+	addOutputFileAlias(cmd)
 
 	return cmd
 }
 
 func newAgeExportCommand() *cobra.Command {
-	return &cobra.Command{
-		Use:   "export",
-		Short: "Export public key (recipient)",
-		RunE:  runAgeExport,
+	cmd := &cobra.Command{
+		Use:         "export",
+		Short:       "Export public key (recipient)",
+		RunE:        runAgeExport,
+		Annotations: map[string]string{outputAnnotation: "pf.recipients/v1"},
 	}
+
+	cmd.Flags().String("store", "", "Export the recipients of this store's identities")
+	cmd.Flags().Bool("json", false, "Output as JSON")
+	cmd.Flags().Bool("qr", false, "Display as QR code")
+
+	return cmd
 }
 
 func newAgeImportCommand() *cobra.Command {
@@ -59,7 +74,35 @@ func newAgeImportCommand() *cobra.Command {
 	return cmd
 }
 
+func newAgePasswdCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "passwd",
+		Short: "Change or remove the passphrase of the age key file",
+		RunE:  runAgePasswd,
+	}
+
+	cmd.Flags().String("file", "", "Key file (default: age_key_path)")
+	cmd.Flags().Bool("remove", false, "Remove the passphrase")
+
+	return cmd
+}
+
 func runAgeGenerate(cmd *cobra.Command, args []string) error {
+	output := cmd.Flag("out-file").Value.String()
+	usePassphrase, _ := cmd.Flags().GetBool("passphrase")
+	if usePassphrase && output == "" {
+		return fmt.Errorf("--passphrase requires --out-file")
+	}
+
+	// Read passphrase before generating anything
+	passphrase := ""
+	if usePassphrase {
+		var err error
+		if passphrase, err = readNewPassphrase(); err != nil {
+			return err
+		}
+	}
+
 	// Generate key pair
 	keyPair, err := age.GenerateKeyPair()
 	if err != nil {
@@ -69,10 +112,11 @@ func runAgeGenerate(cmd *cobra.Command, args []string) error {
 	// Display key information
 	cmd.Println("Generated age key pair:")
 	cmd.Printf("Public key (recipient): %s\n", keyPair.Recipient)
-	cmd.Printf("Private key (identity): %s\n", keyPair.Identity)
+	if !usePassphrase {
+		cmd.Printf("Private key (identity): %s\n", keyPair.Identity)
+	}
 
 	// Save to file if requested
-	output := cmd.Flag("output").Value.String()
 	if output != "" {
 		// Ensure directory exists
 		dir := filepath.Dir(output)
@@ -83,11 +127,14 @@ func runAgeGenerate(cmd *cobra.Command, args []string) error {
 		// Write key file
 		keyData := fmt.Sprintf("# Age private key for pf password manager\n# Public key: %s\n%s\n",
 			keyPair.Recipient, keyPair.Identity)
-		if err := os.WriteFile(output, []byte(keyData), 0600); err != nil {
+		if err := writeKeyFile(output, keyData, passphrase); err != nil {
 			return fmt.Errorf("failed to write key file: %w", err)
 		}
 
 		cmd.Printf("\nPrivate key saved to: %s\n", output)
+		if usePassphrase {
+			cmd.Println("The key file is protected with your passphrase.")
+		}
 		cmd.Println("Keep this file secure!")
 	} else {
 		cmd.Println("\nWARNING: Private key not saved to file.")
@@ -98,30 +145,78 @@ func runAgeGenerate(cmd *cobra.Command, args []string) error {
 }
 
 func runAgeExport(cmd *cobra.Command, args []string) error {
-	// Load config to get key path
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Load identity file
-	identities, err := age.LoadIdentityFile(cfg.AgeKeyPath)
+	// Load the identities of the store, or the global ones
+	storeName, _ := cmd.Flags().GetString("store")
+	if storeName != "" {
+		if _, ok := cfg.Stores[storeName]; !ok {
+			return fmt.Errorf("store '%s' not found", storeName)
+		}
+	}
+	identities, err := keyring.New(cfg.IdentitySources(storeName)).Load()
+	if len(identities) == 0 {
+		if err != nil {
+			return fmt.Errorf("failed to load identities: %w", err)
+		}
+		return fmt.Errorf("no identities found")
+	}
 	if err != nil {
-		return fmt.Errorf("failed to load age key: %w", err)
+		cmd.PrintErrf("Warning: %v\n", err)
 	}
 
-	if len(identities) == 0 {
-		return fmt.Errorf("no identities found in key file")
+	type exported struct {
+		Type      string `json:"type" yaml:"type"`
+		Recipient string `json:"recipient" yaml:"recipient"`
+	}
+	var recipients []exported
+	seen := make(map[string]bool)
+	for _, identity := range identities {
+		if identity.Recipient != "" && !seen[identity.Recipient] {
+			seen[identity.Recipient] = true
+			recipients = append(recipients, exported{Type: identity.Type, Recipient: identity.Recipient})
+		}
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("no recipient can be derived from the configured identities")
+	}
+
+	// Export recipients, --json predates --output and keeps its bare array
+	if structuredOutput() {
+		return writeOutput(cmd, recipients)
+	}
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(recipients)
 	}
 
-	// Export recipients
-	cmd.Println("Age recipients (public keys):")
-	cmd.Println("Note: Recipients cannot be derived from loaded identity files")
-	cmd.Println("Please check your .recipients file or original key generation output")
+	showQR, _ := cmd.Flags().GetBool("qr")
+	for _, r := range recipients {
+		if showQR {
+			if err := qrterm.Render(os.Stdout, r.Recipient); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(os.Stdout, r.Recipient)
+	}
 
 	return nil
 }
 
+// identityRecipient returns the first recipient derivable from identities
+func identityRecipient(identities []*age.Identity) string {
+	for _, identity := range identities {
+		if identity.Recipient != "" {
+			return identity.Recipient
+		}
+	}
+	return ""
+}
+
 func runAgeImport(cmd *cobra.Command, args []string) error {
 	keyFile := args[0]
 
@@ -165,9 +260,106 @@ func runAgeImport(cmd *cobra.Command, args []string) error {
 
 	cmd.Printf("Age key imported successfully to: %s\n", cfg.AgeKeyPath)
 
+	// Identity sources replace the key file, so it is added to them
+	if useKeyFile(cfg, cfg.AgeKeyPath) {
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+		cmd.Println("Added to the configured identity sources")
+	}
+
 	// Show imported recipients
-	cmd.Println("\nAge key imported. Recipients cannot be shown from identity file.")
-	cmd.Println("Save your recipient (public key) separately when generating keys.")
+	cmd.Println("\nRecipients (public keys):")
+	for _, identity := range identities {
+		if identity.Recipient != "" {
+			cmd.Printf("  %s\n", identity.Recipient)
+		} else {
+			cmd.Printf("  (%s identity, recipient unknown)\n", identity.Type)
+		}
+	}
+
+	return nil
+}
+
+func runAgePasswd(cmd *cobra.Command, args []string) error {
+	keyFile := cmd.Flag("file").Value.String()
+	if keyFile == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		keyFile = cfg.AgeKeyPath
+	}
+
+	// Read and decrypt the current key file
+	keyData, err := age.ReadIdentityFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load age key: %w", err)
+	}
+	if _, err := age.ParseIdentities(keyData); err != nil {
+		return fmt.Errorf("failed to load age key: %w", err)
+	}
+
+	passphrase := ""
+	if remove, _ := cmd.Flags().GetBool("remove"); !remove {
+		if passphrase, err = readNewPassphrase(); err != nil {
+			return err
+		}
+	}
+
+	if err := writeKeyFile(keyFile, string(keyData), passphrase); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+
+	if passphrase == "" {
+		cmd.Printf("Passphrase removed from %s\n", keyFile)
+	} else {
+		cmd.Printf("Passphrase updated for %s\n", keyFile)
+	}
+	return nil
+}
+
+// readNewPassphrase prompts for a new passphrase and its confirmation
+func readNewPassphrase() (string, error) {
+	passphrase, err := age.ReadPassphrase("Enter new passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase cannot be empty")
+	}
+
+	confirm, err := age.ReadPassphrase("Confirm passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirm {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+
+	return passphrase, nil
+}
+
+// writeKeyFile atomically writes identity file contents, encrypting them
+// when a passphrase is given
+func writeKeyFile(path, keyData, passphrase string) error {
+	data := []byte(keyData)
+	if passphrase != "" {
+		encrypted, err := age.EncryptIdentityFile(keyData, passphrase)
+		if err != nil {
+			return err
+		}
+		data = encrypted
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/agent"
+	"pf/internal/config"
+	"pf/internal/daemon"
+	"pf/internal/keyring"
+)
+
+// NewAgentCommand creates the agent command
+func NewAgentCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Manage the identity agent",
+		Long: `The agent keeps decrypted age identities in memory and decrypts on behalf
+of other pf commands, so the key file is not read on every call.
+
+It listens on a Unix socket ($PF_AGENT_SOCK, $XDG_RUNTIME_DIR/pf-agent.sock
+or ~/.pf/agent.sock) and only answers processes of the same user.`,
+	}
+
+	cmd.AddCommand(
+		newAgentStartCommand(),
+		newAgentAddCommand(),
+		newAgentLockCommand(),
+		newAgentStatusCommand(),
+		newAgentStopCommand(),
+	)
+
+	return cmd
+}
+
+func newAgentStartCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start the agent and load the age key",
+		RunE:  runAgentStart,
+	}
+
+	cmd.Flags().Duration("timeout", 15*time.Minute, "Forget identities after this idle time (0 = never)")
+	cmd.Flags().Bool("foreground", false, "Run in the foreground")
+	cmd.Flags().Bool("no-add", false, "Start without loading the age key")
+
+	return cmd
+}
+
+func newAgentAddCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add [file]",
+		Short: "Load identities into the agent (default: all identity sources)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  runAgentAdd,
+	}
+}
+
+func newAgentLockCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "lock",
+		Short: "Make the agent forget all identities",
+		RunE:  runAgentLock,
+	}
+}
+
+func newAgentStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:         "status",
+		Short:       "Show agent status",
+		RunE:        runAgentStatus,
+		Annotations: map[string]string{outputAnnotation: "pf.agent-status/v1"},
+	}
+}
+
+func newAgentStopCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the agent",
+		RunE:  runAgentStop,
+	}
+}
+
+func runAgentStart(cmd *cobra.Command, args []string) error {
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	if foreground, _ := cmd.Flags().GetBool("foreground"); foreground {
+		return serveAgent(timeout)
+	}
+
+	if _, err := agent.Dial(); err == nil {
+		return fmt.Errorf("agent already running on %s", agent.SocketPath())
+	}
+
+	// Start the agent in the background
+	pid, err := daemon.Start([]string{"agent", "start", "--foreground", "--timeout", timeout.String()}, nil)
+	if err != nil {
+		return err
+	}
+
+	// Wait for the socket to come up
+	var client *agent.Client
+	for i := 0; i < 50; i++ {
+		if client, err = agent.Dial(); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if client == nil {
+		return fmt.Errorf("agent did not start: %w", err)
+	}
+
+	cmd.Printf("Agent started (pid %d) on %s\n", pid, agent.SocketPath())
+
+	if noAdd, _ := cmd.Flags().GetBool("no-add"); noAdd {
+		return nil
+	}
+	return runAgentAdd(cmd, nil)
+}
+
+func serveAgent(timeout time.Duration) error {
+	listener, err := agent.Listen(agent.SocketPath())
+	if err != nil {
+		return err
+	}
+
+	server := agent.NewServer(timeout)
+
+	// Remove the socket on termination
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		<-signals
+		server.Lock()
+		listener.Close()
+	}()
+
+	return server.Serve(listener)
+}
+
+func runAgentAdd(cmd *cobra.Command, args []string) error {
+	var sources []config.IdentitySource
+	if len(args) > 0 {
+		sources = []config.IdentitySource{{File: args[0]}}
+	} else {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		sources = allIdentitySources(cfg)
+	}
+
+	client, err := agent.Dial()
+	if err != nil {
+		return fmt.Errorf("agent is not running: %w", err)
+	}
+
+	var lines []string
+	for _, source := range sources {
+		identities, err := keyring.LoadSource(source)
+		if err != nil {
+			// Keep loading the other sources, some may be unavailable
+			cmd.Printf("Warning: %v\n", err)
+			continue
+		}
+
+		for _, identity := range identities {
+			line, err := identity.Encode()
+			if err != nil {
+				return err
+			}
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("no identities could be loaded")
+	}
+
+	if err := client.Add(lines); err != nil {
+		return fmt.Errorf("failed to add identities: %w", err)
+	}
+
+	cmd.Printf("Added %d identities from %d sources\n", len(lines), len(sources))
+	return nil
+}
+
+func runAgentLock(cmd *cobra.Command, args []string) error {
+	client, err := agent.Dial()
+	if err != nil {
+		return fmt.Errorf("agent is not running: %w", err)
+	}
+
+	if err := client.Lock(); err != nil {
+		return fmt.Errorf("failed to lock agent: %w", err)
+	}
+
+	cmd.Println("Agent locked")
+	return nil
+}
+
+func runAgentStatus(cmd *cobra.Command, args []string) error {
+	client, err := agent.Dial()
+	if err != nil {
+		if structuredOutput() {
+			return writeOutput(cmd, agentStatus{Socket: agent.SocketPath()})
+		}
+		cmd.Println("Agent is not running")
+		return nil
+	}
+
+	status, err := client.Status()
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return writeOutput(cmd, agentStatus{
+			Running:     true,
+			PID:         status.PID,
+			Socket:      agent.SocketPath(),
+			Identities:  status.Identities,
+			IdleTimeout: status.Timeout.String(),
+		})
+	}
+
+	cmd.Printf("Agent running (pid %d) on %s\n", status.PID, agent.SocketPath())
+	if status.Identities == 0 {
+		cmd.Println("  Locked")
+	} else {
+		cmd.Printf("  Identities: %d\n", status.Identities)
+	}
+	if status.Timeout > 0 {
+		cmd.Printf("  Idle timeout: %s\n", status.Timeout)
+	}
+
+	return nil
+}
+
+// agentStatus is the data of a pf.agent-status/v1 document. The agent is
+// locked when it runs without identities.
+type agentStatus struct {
+	Running     bool   `json:"running" yaml:"running"`
+	PID         int    `json:"pid,omitempty" yaml:"pid,omitempty"`
+	Socket      string `json:"socket" yaml:"socket"`
+	Identities  int    `json:"identities" yaml:"identities"`
+	IdleTimeout string `json:"idle_timeout,omitempty" yaml:"idle_timeout,omitempty"`
+}
+
+func runAgentStop(cmd *cobra.Command, args []string) error {
+	client, err := agent.Dial()
+	if err != nil {
+		return fmt.Errorf("agent is not running: %w", err)
+	}
+
+	if err := client.Stop(); err != nil {
+		return fmt.Errorf("failed to stop agent: %w", err)
+	}
+
+	cmd.Println("Agent stopped")
+	return nil
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/config"
+	"pf/internal/store"
+)
+
+// NewAWSCredentialsCommand creates the aws-credentials command
+func NewAWSCredentialsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "aws-credentials [key]",
+		Short: "Print AWS credentials for credential_process",
+		Long: `Print an entry as the JSON expected from an AWS credential_process.
+
+The password is the secret access key, an "access_key_id: ..." field holds
+the access key ID and an optional "session_token: ..." field the session
+token. The entry's expiry date, if set, is reported as the expiration.
+
+  [profile prod]
+  credential_process = pf aws-credentials aws/prod`,
+		Args:        cobra.ExactArgs(1),
+		RunE:        runAWSCredentials,
+		Annotations: map[string]string{outputAnnotation: fixedOutput},
+	}
+
+	cmd.Flags().String("store", "", "Store name")
+
+	return cmd
+}
+
+// awsCredentials is the credential_process output, version 1
+type awsCredentials struct {
+	Version         int
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string `json:",omitempty"`
+	Expiration      string `json:",omitempty"`
+}
+
+func runAWSCredentials(cmd *cobra.Command, args []string) error {
+	key := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	s, _, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	secret, err := s.Get(key, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get password: %w", err)
+	}
+
+	password, fields := store.ParseFields(secret)
+	if fields["access_key_id"] == "" {
+		return fmt.Errorf("'%s' has no access_key_id field", key)
+	}
+
+	creds := awsCredentials{
+		Version:         1,
+		AccessKeyId:     fields["access_key_id"],
+		SecretAccessKey: password,
+		SessionToken:    fields["session_token"],
+	}
+
+	entry, err := s.Info(key)
+	if err != nil {
+		return fmt.Errorf("failed to get entry info: %w", err)
+	}
+	if expires, ok := entry.ExpiresAt(); ok {
+		creds.Expiration = expires.UTC().Format(time.RFC3339)
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(creds)
+}
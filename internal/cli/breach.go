@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/breach"
+	"pf/internal/config"
+	"pf/internal/store"
+)
+
+// NewBreachCommand creates the breach command
+func NewBreachCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "breach",
+		Short: "Check passwords against known breaches",
+		Long: `Check passwords against a local copy of the Have I Been Pwned
+password hashes. Nothing is sent over the network.`,
+	}
+
+	cmd.AddCommand(
+		newBreachCheckCommand(),
+		newBreachIndexCommand(),
+	)
+
+	return cmd
+}
+
+func newBreachCheckCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "check",
+		Short:       "List passwords found in the breach dataset",
+		RunE:        runBreachCheck,
+		Annotations: map[string]string{outputAnnotation: "pf.breach/v1"},
+	}
+
+	cmd.Flags().String("store", "", "Store name")
+	cmd.Flags().String("dataset", "", "HIBP SHA-1 ordered-by-hash file or compact index (default: breach_dataset from config)")
+
+	return cmd
+}
+
+func newBreachIndexCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "Build a compact index from the HIBP text file",
+		RunE:  runBreachIndex,
+	}
+
+	cmd.Flags().String("dataset", "", "HIBP SHA-1 ordered-by-hash file")
+	cmd.Flags().String("out-file", "", "Output file for the compact index")
+	cmd.MarkFlagRequired("dataset")
+	cmd.MarkFlagRequired("out-file")
+	addOutputFileAlias(cmd)
+
+	return cmd
+}
+
+func runBreachCheck(cmd *cobra.Command, args []string) error {
+	// Load config
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	datasetPath := cmd.Flag("dataset").Value.String()
+	if datasetPath == "" {
+		datasetPath = cfg.BreachDataset
+	}
+	if datasetPath == "" {
+		return fmt.Errorf("no breach dataset specified (use --dataset or set breach_dataset)")
+	}
+
+	s, storeName, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	dataset, err := breach.Open(datasetPath)
+	if err != nil {
+		return err
+	}
+	defer dataset.Close()
+
+	keys, err := s.List()
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	// Check the password of the latest version of every entry, entries
+	// that cannot be decrypted are reported as skipped
+	var compromised, skipped []string
+	for _, key := range keys {
+		secret, err := s.Get(key, 0)
+		if err != nil {
+			cmd.PrintErrf("Warning: skipping '%s': %v\n", key, err)
+			skipped = append(skipped, key)
+			continue
+		}
+
+		password, _ := store.ParseFields(secret)
+		found, err := dataset.Contains(password)
+		if err != nil {
+			return fmt.Errorf("failed to check password '%s': %w", key, err)
+		}
+		if found {
+			compromised = append(compromised, key)
+		}
+	}
+
+	checked := len(keys) - len(skipped)
+
+	if structuredOutput() {
+		return writeOutput(cmd, breachReport{
+			Store:    storeName,
+			Checked:  checked,
+			Breached: append([]string{}, compromised...),
+			Skipped:  append([]string{}, skipped...),
+		})
+	}
+
+	if len(compromised) == 0 {
+		cmd.Printf("No breached passwords found in store '%s' (%d checked)\n", storeName, checked)
+	} else {
+		cmd.Printf("Breached passwords in store '%s':\n", storeName)
+		for _, key := range compromised {
+			cmd.Printf("  %s\n", key)
+		}
+		cmd.Printf("\n%d of %d passwords found in the breach dataset\n", len(compromised), checked)
+	}
+	if len(skipped) > 0 {
+		cmd.Printf("%d passwords could not be read and were skipped\n", len(skipped))
+	}
+
+	return nil
+}
+
+// breachReport is the data of a pf.breach/v1 document
+type breachReport struct {
+	Store    string   `json:"store" yaml:"store"`
+	Checked  int      `json:"checked" yaml:"checked"`
+	Breached []string `json:"breached" yaml:"breached"`
+	Skipped  []string `json:"skipped" yaml:"skipped"`
+}
+
+func runBreachIndex(cmd *cobra.Command, args []string) error {
+	datasetPath := cmd.Flag("dataset").Value.String()
+	output := cmd.Flag("out-file").Value.String()
+
+	src, err := os.Open(datasetPath)
+	if err != nil {
+		return fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+
+	count, err := breach.BuildIndex(src, dst)
+	if err != nil {
+		dst.Close()
+		os.Remove(output)
+		return fmt.Errorf("failed to build index: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+
+	cmd.Printf("Indexed %d hashes to: %s\n", count, output)
+	return nil
+}
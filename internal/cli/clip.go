@@ -0,0 +1,333 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/agent"
+	"pf/internal/clipboard"
+	"pf/internal/config"
+	"pf/internal/daemon"
+)
+
+// NewClipCommand creates the clip command
+func NewClipCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clip",
+		Short: "Manage passwords copied to the clipboard",
+		Long: `Passwords copied to the clipboard are cleared by a background process
+after clipboard_timeout, which restores what the clipboard held before. The
+clipboard is left alone if something else was copied in the meantime.
+
+The clipboard backend is detected from the environment, or set in the
+config file:
+
+  clipboard:
+    backend: osc52    # auto, system, osc52, wl-copy, xclip, xsel or file
+    path: /dev/pts/3  # file of the file backend, terminal of osc52
+
+OSC 52 sets the clipboard of the terminal emulator, also over SSH and
+through tmux. Terminals can't be read back, so that clipboard, like a file
+backend on a FIFO, is cleared after the timeout whatever it holds.`,
+	}
+
+	cmd.AddCommand(
+		newClipClearCommand(),
+		newClipWatchCommand(),
+	)
+
+	return cmd
+}
+
+func newClipClearCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "Clear a copied password from the clipboard now",
+		Args:  cobra.NoArgs,
+		RunE:  runClipClear,
+	}
+}
+
+func newClipWatchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "watch",
+		Short:  "Clear the clipboard after a timeout (started by copying)",
+		Args:   cobra.NoArgs,
+		Hidden: true,
+		RunE:   runClipWatch,
+	}
+
+	cmd.Flags().Duration("timeout", 45*time.Second, "Clear the clipboard after this long")
+
+	return cmd
+}
+
+// clipState is what the clearing process knows of a copy: the clipboard
+// backend, the hash of the copied password and the contents to restore
+type clipState struct {
+	Backend  string `json:"backend"`
+	Path     string `json:"path,omitempty"`
+	Hash     string `json:"hash"`
+	Previous string `json:"previous"`
+}
+
+// Requests to the clearing process
+const (
+	clipRequestClear    = "clear"
+	clipRequestHandover = "handover"
+)
+
+// clipSocketPath returns the socket of the clearing process
+func clipSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "pf-clip.sock")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".pf", "clip.sock")
+}
+
+func clipHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// openClipboard returns the clipboard backend configured in cfg
+func openClipboard(cfg *config.Config) (clipboard.Backend, error) {
+	return clipboard.New(cfg.Clipboard.Backend, cfg.Clipboard.Path)
+}
+
+// copyToClipboard copies value to the clipboard and, for a positive timeout,
+// starts a process that clears it after timeout
+func copyToClipboard(cfg *config.Config, value string, timeout time.Duration) error {
+	backend, err := openClipboard(cfg)
+	if err != nil {
+		return err
+	}
+
+	previous, _ := backend.Read()
+
+	// A pending copy hands over what it would restore, so copying twice
+	// still restores the contents from before the first copy
+	if pending, err := requestClip(clipRequestHandover); err == nil {
+		var state clipState
+		if json.Unmarshal([]byte(pending), &state) == nil && state.Hash == clipHash(previous) {
+			previous = state.Previous
+		}
+	}
+
+	if err := backend.Write(value); err != nil {
+		return fmt.Errorf("failed to copy to clipboard: %w", err)
+	}
+
+	if timeout <= 0 {
+		return nil
+	}
+	state := clipState{
+		Backend:  backend.Name(),
+		Path:     backend.Path(),
+		Hash:     clipHash(value),
+		Previous: previous,
+	}
+	if err := startClipWatch(state, timeout); err != nil {
+		return fmt.Errorf("failed to schedule clipboard clearing: %w", err)
+	}
+	return nil
+}
+
+// printCopied reports that what was copied and when it is cleared
+func printCopied(cmd *cobra.Command, what string, timeout time.Duration) {
+	if timeout <= 0 {
+		cmd.Printf("%s copied to clipboard.\n", what)
+		return
+	}
+	cmd.Printf("%s copied to clipboard. Will clear in %s.\n", what, timeout)
+}
+
+// startClipWatch starts the clearing process and waits for it to listen
+func startClipWatch(state clipState, timeout time.Duration) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	// The state goes through a pipe rather than arguments, which other
+	// users can read
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = daemon.Start([]string{"clip", "watch", "--timeout", timeout.String()}, r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	w.Close()
+
+	for i := 0; i < 50; i++ {
+		if conn, err := net.Dial("unix", clipSocketPath()); err == nil {
+			conn.Close()
+			return nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return fmt.Errorf("clearing process did not start")
+}
+
+// requestClip sends a request to the clearing process and returns its reply
+func requestClip(request string) (string, error) {
+	conn, err := net.Dial("unix", clipSocketPath())
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := fmt.Fprintln(conn, request); err != nil {
+		return "", err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(reply, "\n"), nil
+}
+
+// clearClipboard clears a copied password now, through the clearing process
+// when one is pending and directly otherwise
+func clearClipboard(cfg *config.Config) error {
+	if _, err := requestClip(clipRequestClear); err == nil {
+		return nil
+	}
+
+	backend, err := openClipboard(cfg)
+	if err != nil {
+		return err
+	}
+	if err := backend.Write(""); err != nil {
+		return fmt.Errorf("failed to clear clipboard: %w", err)
+	}
+	return nil
+}
+
+func runClipClear(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := clearClipboard(cfg); err != nil {
+		return err
+	}
+	cmd.Println("Clipboard cleared")
+	return nil
+}
+
+func runClipWatch(cmd *cobra.Command, args []string) error {
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	var state clipState
+	if err := json.NewDecoder(os.Stdin).Decode(&state); err != nil {
+		return fmt.Errorf("failed to read clipboard state: %w", err)
+	}
+
+	backend, err := clipboard.New(state.Backend, state.Path)
+	if err != nil {
+		return err
+	}
+
+	listener, err := agent.Listen(clipSocketPath())
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	conns := make(chan net.Conn)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+
+	// Clear early when the session ends
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			restoreClipboard(backend, state)
+			return nil
+		case <-signals:
+			restoreClipboard(backend, state)
+			return nil
+		case conn := <-conns:
+			if done := handleClipRequest(conn, listener, backend, state); done {
+				return nil
+			}
+		}
+	}
+}
+
+// handleClipRequest answers a request and reports whether the clearing
+// process is done. The listener is closed before answering those, so the
+// socket is free for the next clearing process.
+func handleClipRequest(conn net.Conn, listener net.Listener, backend clipboard.Backend, state clipState) bool {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	request, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	switch strings.TrimSpace(request) {
+	case clipRequestClear:
+		restoreClipboard(backend, state)
+		listener.Close()
+		fmt.Fprintln(conn, "ok")
+	case clipRequestHandover:
+		data, _ := json.Marshal(state)
+		listener.Close()
+		fmt.Fprintf(conn, "%s\n", data)
+	default:
+		return false
+	}
+	return true
+}
+
+// restoreClipboard restores the previous contents if the clipboard still
+// holds the copied password. Clipboards that can't be read are cleared.
+func restoreClipboard(backend clipboard.Backend, state clipState) {
+	current, err := backend.Read()
+	switch {
+	case errors.Is(err, clipboard.ErrReadUnsupported):
+		backend.Write("")
+	case err == nil && clipHash(current) == state.Hash:
+		backend.Write(state.Previous)
+	}
+}
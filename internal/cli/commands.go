@@ -1,7 +1,16 @@
 package cli
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/spf13/cobra"
+
+	"pf/internal/config"
+	"pf/internal/keyring"
+	"pf/internal/store"
 )
 
 // NewRootCommand creates the root command
@@ -13,11 +22,25 @@ func NewRootCommand() *cobra.Command {
 
 It provides a simple interface for storing and retrieving passwords,
 with features like versioning, multiple stores, and audit logging.`,
+
+		// main prints the error and picks the exit code
+		SilenceErrors: true,
+
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return setupOutput(cmd)
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			return finishOutput(cmd)
+		},
 	}
 
+	// No shorthand, -o is inject's output file
+	cmd.PersistentFlags().StringVar(&outputFormat, "output", OutputText, "Output format: text, json or yaml")
+
 	cmd.AddCommand(
 		NewInitCommand(),
 		NewGetCommand(),
+		NewOTPCommand(),
 		NewPutCommand(),
 		NewDeleteCommand(),
 		NewListCommand(),
@@ -26,7 +49,62 @@ with features like versioning, multiple stores, and audit logging.`,
 		NewStoreCommand(),
 		NewConfigCommand(),
 		NewAgeCommand(),
+		NewAgentCommand(),
+		NewBreachCommand(),
+		NewExpireCommand(),
+		NewExpiringCommand(),
+		NewServeCommand(),
+		NewExecCommand(),
+		NewInjectCommand(),
+		NewGitCredentialCommand(),
+		NewDockerCredentialCommand(),
+		NewAWSCredentialsCommand(),
+		NewNetrcCommand(),
+		NewSSHAddCommand(),
+		NewSSHKeygenCommand(),
+		NewUICommand(),
+		NewPickCommand(),
+		NewClipCommand(),
+		NewEditCommand(),
 	)
 
 	return cmd
+}
+
+// helperCommands maps the names pf can be linked as to the command they run
+var helperCommands = map[string]string{
+	"git-credential-pf":    "git-credential",
+	"docker-credential-pf": "docker-credential",
+}
+
+// CommandArgs returns the command-line arguments to run for os.Args. When pf
+// is invoked through a helper link such as git-credential-pf, the helper's
+// command is prepended.
+func CommandArgs(osArgs []string) []string {
+	name := strings.TrimSuffix(filepath.Base(osArgs[0]), ".exe")
+	if command, ok := helperCommands[name]; ok {
+		return append([]string{command}, osArgs[1:]...)
+	}
+	return osArgs[1:]
+}
+
+// openStore opens the store selected by the --store flag, or the default store
+func openStore(cmd *cobra.Command, cfg *config.Config) (*store.Store, string, error) {
+	storeName, _ := cmd.Flags().GetString("store")
+	if storeName == "" {
+		storeName = cfg.DefaultStore
+	}
+
+	storeConfig, ok := cfg.Stores[storeName]
+	if !ok {
+		return nil, "", fmt.Errorf("store '%s' not found", storeName)
+	}
+
+	s, err := store.New(storeConfig.Path, keyring.New(cfg.IdentitySources(storeName)))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to initialize store: %w", err)
+	}
+	s.SetAuthor(os.Getenv("USER"))
+
+	return s, storeName, nil
 }
\ No newline at end of file
@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 	
 	"pf/internal/config"
+	"pf/internal/keyring"
 	"pf/internal/store"
 )
 
@@ -36,7 +37,7 @@ func passwordKeyCompletion(cmd *cobra.Command, args []string, toComplete string)
 	}
 
 	// Initialize store
-	s, err := store.New(storeConfig.Path, cfg.AgeKeyPath)
+	s, err := store.New(storeConfig.Path, keyring.New(cfg.IdentitySources(storeName)))
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
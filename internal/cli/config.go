@@ -2,10 +2,13 @@ package cli
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 
+	"pf/internal/clipboard"
 	"pf/internal/config"
 )
 
@@ -28,18 +31,20 @@ func NewConfigCommand() *cobra.Command {
 
 func newConfigShowCommand() *cobra.Command {
 	return &cobra.Command{
-		Use:   "show",
-		Short: "Show all configuration",
-		RunE:  runConfigShow,
+		Use:         "show",
+		Short:       "Show all configuration",
+		RunE:        runConfigShow,
+		Annotations: map[string]string{outputAnnotation: "pf.config/v1"},
 	}
 }
 
 func newConfigGetCommand() *cobra.Command {
 	return &cobra.Command{
-		Use:   "get [key]",
-		Short: "Get a configuration value",
-		Args:  cobra.ExactArgs(1),
-		RunE:  runConfigGet,
+		Use:         "get [key]",
+		Short:       "Get a configuration value",
+		Args:        cobra.ExactArgs(1),
+		RunE:        runConfigGet,
+		Annotations: map[string]string{outputAnnotation: "pf.config-value/v1"},
 	}
 }
 
@@ -64,6 +69,15 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
+	if structuredOutput() {
+		// The config file's keys are the schema
+		var values map[string]any
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+		return writeOutput(cmd, values)
+	}
+
 	cmd.Printf("Configuration:\n%s", string(data))
 	return nil
 }
@@ -76,22 +90,51 @@ func runConfigGet(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	value := configValue{Key: key}
 	switch key {
 	case "default_store":
-		cmd.Println(cfg.DefaultStore)
+		value.Value = cfg.DefaultStore
 	case "age_key_path":
-		cmd.Println(cfg.AgeKeyPath)
+		value.Value = cfg.AgeKeyPath
 	case "audit_log":
-		cmd.Println(cfg.AuditLog)
+		value.Value = fmt.Sprint(cfg.AuditLog)
 	case "clipboard_timeout":
-		cmd.Printf("%s\n", cfg.ClipboardTimeout)
+		value.Value = cfg.ClipboardTimeout.String()
+	case "clipboard_backend":
+		value.Value = cfg.Clipboard.Backend
+		if value.Value == "" || value.Value == clipboard.Auto {
+			value.Value = clipboard.Auto
+			value.Detected = clipboard.Detect()
+		}
+	case "clipboard_path":
+		value.Value = cfg.Clipboard.Path
+	case "breach_dataset":
+		value.Value = cfg.BreachDataset
 	default:
 		return fmt.Errorf("unknown configuration key: %s", key)
 	}
 
+	if structuredOutput() {
+		return writeOutput(cmd, value)
+	}
+
+	if value.Detected != "" {
+		cmd.Printf("%s (detected: %s)\n", value.Value, value.Detected)
+	} else {
+		cmd.Println(value.Value)
+	}
 	return nil
 }
 
+// configValue is the data of a pf.config-value/v1 document
+type configValue struct {
+	Key   string `json:"key" yaml:"key"`
+	Value string `json:"value" yaml:"value"`
+
+	// Detected is the backend in use when clipboard_backend is auto
+	Detected string `json:"detected,omitempty" yaml:"detected,omitempty"`
+}
+
 func runConfigSet(cmd *cobra.Command, args []string) error {
 	key := args[0]
 	value := args[1]
@@ -112,6 +155,15 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 		cfg.AgeKeyPath = value
 	case "audit_log":
 		cfg.AuditLog = value == "true"
+	case "breach_dataset":
+		cfg.BreachDataset = value
+	case "clipboard_backend":
+		if !slices.Contains(clipboard.Backends, value) {
+			return fmt.Errorf("unknown clipboard backend '%s' (expected one of %s)", value, strings.Join(clipboard.Backends, ", "))
+		}
+		cfg.Clipboard.Backend = value
+	case "clipboard_path":
+		cfg.Clipboard.Path = value
 	default:
 		return fmt.Errorf("unknown configuration key: %s", key)
 	}
@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"pf/internal/config"
+	"pf/internal/keyring"
 	"pf/internal/store"
 )
 
@@ -50,7 +51,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize store
-	s, err := store.New(storeConfig.Path, cfg.AgeKeyPath)
+	s, err := store.New(storeConfig.Path, keyring.New(cfg.IdentitySources(storeName)))
 	if err != nil {
 		return fmt.Errorf("failed to initialize store: %w", err)
 	}
@@ -58,7 +59,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	// Confirm deletion
 	force, _ := cmd.Flags().GetBool("force")
 	if !force {
-		fmt.Printf("Are you sure you want to delete '%s'? [y/N] ", key)
+		fmt.Fprintf(os.Stderr, "Are you sure you want to delete '%s'? [y/N] ", key)
 		reader := bufio.NewReader(os.Stdin)
 		response, err := reader.ReadString('\n')
 		if err != nil {
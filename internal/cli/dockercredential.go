@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/config"
+	"pf/internal/store"
+)
+
+// dockerNotFound is the message Docker recognizes as missing credentials
+const dockerNotFound = "credentials not found in native keychain"
+
+// NewDockerCredentialCommand creates the docker-credential command
+func NewDockerCredentialCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "docker-credential",
+		Short: "Docker credential helper",
+		Long: `Docker credential helper implementing store, get, erase and list.
+
+Registry credentials are stored as the secret followed by "username: ..."
+and "server_url: ..." fields, under a key built from the server URL below
+the docker_credential.prefix in the config (default: docker), e.g.
+https://index.docker.io/v1/ is stored as docker/index.docker.io/v1.
+
+Link the pf binary as docker-credential-pf somewhere in $PATH and set
+"credsStore": "pf" in ~/.docker/config.json.`,
+		Annotations: map[string]string{outputAnnotation: fixedOutput},
+	}
+
+	cmd.PersistentFlags().String("store", "", "Store name (default: docker_credential.store or the default store)")
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "store",
+			Short: "Store credentials read as JSON from stdin",
+			Args:  cobra.NoArgs,
+			Run:   dockerCredentialRun(runDockerCredentialStore),
+		},
+		&cobra.Command{
+			Use:   "get",
+			Short: "Print the credentials of the server URL read from stdin",
+			Args:  cobra.NoArgs,
+			Run:   dockerCredentialRun(runDockerCredentialGet),
+		},
+		&cobra.Command{
+			Use:   "erase",
+			Short: "Erase the credentials of the server URL read from stdin",
+			Args:  cobra.NoArgs,
+			Run:   dockerCredentialRun(runDockerCredentialErase),
+		},
+		&cobra.Command{
+			Use:   "list",
+			Short: "List server URLs and usernames",
+			Args:  cobra.NoArgs,
+			Run:   dockerCredentialRun(runDockerCredentialList),
+		},
+	)
+
+	return cmd
+}
+
+// dockerCredentials is the JSON form of credentials in the helper protocol
+type dockerCredentials struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+// dockerCredentialRun adapts a helper operation to the protocol, where
+// errors are written to stdout and missing credentials are reported with
+// dockerNotFound
+func dockerCredentialRun(run func(*store.Store, string) error) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		err := openDockerCredential(cmd, run)
+		if err == nil {
+			return
+		}
+
+		if errors.Is(err, store.ErrNotFound) {
+			fmt.Fprintln(os.Stdout, dockerNotFound)
+		} else {
+			fmt.Fprintln(os.Stdout, err)
+		}
+		os.Exit(1)
+	}
+}
+
+// openDockerCredential opens the credential store and runs an operation
+func openDockerCredential(cmd *cobra.Command, run func(*store.Store, string) error) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if !cmd.Flags().Changed("store") && cfg.DockerCredential.Store != "" {
+		cmd.Flags().Set("store", cfg.DockerCredential.Store)
+	}
+
+	s, _, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	prefix := strings.Trim(cfg.DockerCredential.Prefix, "/")
+	if prefix == "" {
+		prefix = config.DefaultDockerCredentialPrefix
+	}
+
+	return run(s, prefix)
+}
+
+func runDockerCredentialStore(s *store.Store, prefix string) error {
+	var creds dockerCredentials
+	if err := json.NewDecoder(os.Stdin).Decode(&creds); err != nil {
+		return fmt.Errorf("failed to read credentials: %w", err)
+	}
+	if creds.ServerURL == "" {
+		return fmt.Errorf("no credentials server URL")
+	}
+
+	key, err := dockerCredentialKey(prefix, creds.ServerURL)
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]string{
+		"username":   creds.Username,
+		"server_url": creds.ServerURL,
+	}
+
+	// docker login stores on every login, only changes make a new version
+	secret := store.SetFields("", creds.Secret, fields)
+	current, err := s.Get(key, 0)
+	switch {
+	case err == nil:
+		secret = store.SetFields(current, creds.Secret, fields)
+		if secret == current || secret == current+"\n" {
+			return nil
+		}
+	case !errors.Is(err, store.ErrNotFound):
+		return fmt.Errorf("failed to get password: %w", err)
+	}
+
+	if err := s.Put(key, secret, "Stored by docker credential helper"); err != nil {
+		return fmt.Errorf("failed to store password: %w", err)
+	}
+	return nil
+}
+
+func runDockerCredentialGet(s *store.Store, prefix string) error {
+	serverURL, err := readServerURL(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	key, err := dockerCredentialKey(prefix, serverURL)
+	if err != nil {
+		return err
+	}
+
+	secret, err := s.Get(key, 0)
+	if err != nil {
+		return err
+	}
+
+	password, fields := store.ParseFields(secret)
+	return json.NewEncoder(os.Stdout).Encode(dockerCredentials{
+		ServerURL: serverURL,
+		Username:  fields["username"],
+		Secret:    password,
+	})
+}
+
+func runDockerCredentialErase(s *store.Store, prefix string) error {
+	serverURL, err := readServerURL(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	key, err := dockerCredentialKey(prefix, serverURL)
+	if err != nil {
+		return err
+	}
+
+	return s.Delete(key)
+}
+
+func runDockerCredentialList(s *store.Store, prefix string) error {
+	keys, err := s.List()
+	if err != nil {
+		return fmt.Errorf("failed to list passwords: %w", err)
+	}
+
+	servers := make(map[string]string)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix+"/") {
+			continue
+		}
+
+		secret, err := s.Get(key, 0)
+		if err != nil {
+			return fmt.Errorf("failed to get password for '%s': %w", key, err)
+		}
+
+		_, fields := store.ParseFields(secret)
+		serverURL := fields["server_url"]
+		if serverURL == "" {
+			serverURL = strings.TrimPrefix(key, prefix+"/")
+		}
+		servers[serverURL] = fields["username"]
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(servers)
+}
+
+// readServerURL reads the server URL a get or erase request consists of
+func readServerURL(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read server URL: %w", err)
+	}
+
+	serverURL := strings.TrimSpace(string(data))
+	if serverURL == "" {
+		return "", fmt.Errorf("no credentials server URL")
+	}
+	return serverURL, nil
+}
+
+// dockerCredentialKey maps a server URL to a key below prefix, ignoring the
+// scheme and empty path segments
+func dockerCredentialKey(prefix, serverURL string) (string, error) {
+	rest := serverURL
+	if _, after, ok := strings.Cut(rest, "://"); ok {
+		rest = after
+	}
+
+	parts := []string{prefix}
+	for _, part := range strings.Split(rest, "/") {
+		switch part {
+		case "":
+			continue
+		case ".", "..":
+			return "", fmt.Errorf("invalid server URL %q", serverURL)
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 1 {
+		return "", fmt.Errorf("invalid server URL %q", serverURL)
+	}
+
+	return strings.Join(parts, "/"), nil
+}
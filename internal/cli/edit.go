@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"pf/internal/config"
+	"pf/internal/store"
+)
+
+// NewEditCommand creates the edit command
+func NewEditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit [key]",
+		Short: "Edit a password in $EDITOR",
+		Long: `Decrypt the latest version of a password into a temporary file, open it in
+$VISUAL or $EDITOR and store the result as a new version if it changed.
+Missing entries are created.
+
+The file is created with mode 0600 in a private directory on a tmpfs
+(/dev/shm or $XDG_RUNTIME_DIR), and that directory, including editor swap
+and backup files, is overwritten and removed when pf exits, also on errors
+and signals. Use --tmpdir where neither exists, preferably on a tmpfs.`,
+		Example: `  pf edit db/prod
+  EDITOR="code --wait" pf edit db/prod -m "Add replica host"`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              runEdit,
+		ValidArgsFunction: passwordKeyCompletion,
+		Annotations:       map[string]string{outputAnnotation: fixedOutput},
+	}
+
+	cmd.Flags().String("store", "", "Store name")
+	cmd.Flags().StringP("message", "m", "", "Version message (default: prompt)")
+	cmd.Flags().String("tmpdir", "", "Directory for the temporary file (default: /dev/shm or $XDG_RUNTIME_DIR)")
+
+	return cmd
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	tmpDir, _ := cmd.Flags().GetString("tmpdir")
+
+	editor, err := findEditor()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	s, storeName, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	original := ""
+	if s.Exists(key) {
+		if original, err = s.Get(key, 0); err != nil {
+			return fmt.Errorf("failed to get password: %w", err)
+		}
+	} else {
+		cmd.Printf("Creating '%s' in store '%s'\n", key, storeName)
+	}
+
+	if tmpDir == "" {
+		if tmpDir, err = secureTempDir(); err != nil {
+			return err
+		}
+	}
+
+	dir, err := os.MkdirTemp(tmpDir, "pf-edit-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer shredDir(dir)
+
+	// Shred on signals too, the deferred call doesn't run on them
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		shredDir(dir)
+		os.Exit(1)
+	}()
+
+	path := filepath.Join(dir, filepath.Base(key)+".txt")
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	editorCmd := editorCommand(editor, path)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return fmt.Errorf("editor failed, '%s' was not changed: %w", key, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read temporary file: %w", err)
+	}
+	edited := string(data)
+
+	// Editors end files with a newline, entries stored without one keep
+	// ending without
+	if !strings.HasSuffix(original, "\n") {
+		edited = strings.TrimSuffix(edited, "\n")
+	}
+
+	if edited == original {
+		cmd.Printf("No changes to '%s'\n", key)
+		return nil
+	}
+	if strings.TrimSpace(edited) == "" {
+		return fmt.Errorf("password cannot be empty, '%s' was not changed", key)
+	}
+
+	if cfg.BreachDataset != "" {
+		password, _ := store.ParseFields(edited)
+		warnIfBreached(cmd, cfg.BreachDataset, password)
+	}
+
+	message, _ := cmd.Flags().GetString("message")
+	if !cmd.Flags().Changed("message") && term.IsTerminal(int(os.Stdin.Fd())) {
+		cmd.Print("Version message (optional): ")
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		message = strings.TrimSpace(line)
+	}
+
+	if err := s.Put(key, edited, message); err != nil {
+		return fmt.Errorf("failed to store password: %w", err)
+	}
+
+	cmd.Printf("Password stored for '%s' in store '%s'\n", key, storeName)
+	return nil
+}
+
+// findEditor returns the editor command line from $VISUAL or $EDITOR
+func findEditor() (string, error) {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(name)); editor != "" {
+			return editor, nil
+		}
+	}
+
+	fallback := "vi"
+	if runtime.GOOS == "windows" {
+		fallback = "notepad"
+	}
+	if _, err := exec.LookPath(fallback); err != nil {
+		return "", fmt.Errorf("no editor found, set $EDITOR")
+	}
+	return fallback, nil
+}
+
+// editorCommand runs editor on path. Like git, the editor is run by the
+// shell, so it may have quoted arguments.
+func editorCommand(editor, path string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		fields := strings.Fields(editor)
+		return exec.Command(fields[0], append(fields[1:], path)...)
+	}
+	return exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+}
+
+// secureTempDir returns a memory-backed directory for plaintext files
+func secureTempDir() (string, error) {
+	candidates := []string{"/dev/shm", os.Getenv("XDG_RUNTIME_DIR")}
+	for _, dir := range candidates {
+		if dir == "" {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("no tmpfs found (/dev/shm or $XDG_RUNTIME_DIR), use --tmpdir to choose a directory")
+}
+
+// shredDir overwrites the files in dir with zeros and removes it
+func shredDir(dir string) {
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			shredFile(path)
+		}
+		return nil
+	})
+	os.RemoveAll(dir)
+}
+
+// shredFile overwrites a file with zeros and syncs it to disk
+func shredFile(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	zeros := make([]byte, 4096)
+	for remaining := info.Size(); remaining > 0; {
+		n, err := file.Write(zeros[:min(remaining, int64(len(zeros)))])
+		if err != nil {
+			return err
+		}
+		remaining -= int64(n)
+	}
+	if err := file.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	pfage "pf/internal/age"
+	"pf/internal/config"
+	"pf/internal/mask"
+	"pf/internal/store"
+)
+
+// NewExecCommand creates the exec command
+func NewExecCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exec [flags] -- command [args...]",
+		Short: "Run a command with passwords in its environment",
+		Long: `Run a command with passwords exposed as environment variables, so they
+never appear in shell history or process arguments.
+
+Variables are given as NAME=key, or NAME=key@version for an older version,
+with --env or in an --env-file holding one per line. Only the referenced
+passwords are read. Signals are forwarded to the command and pf exits with
+the command's exit code.
+
+The command gets the passwords and only basic variables such as PATH, HOME,
+USER, TERM and LANG from pf's environment, unless --inherit-env is given.`,
+		Example: `  pf exec --env DB_PASS=db/prod -- ./migrate
+  pf exec --env-file .pfenv --mask -- make deploy`,
+		Args:        cobra.MinimumNArgs(1),
+		RunE:        runExec,
+		Annotations: map[string]string{outputAnnotation: fixedOutput},
+	}
+
+	cmd.Flags().StringArray("env", nil, "Variable as NAME=key[@version] (repeatable)")
+	cmd.Flags().String("env-file", "", "File with one NAME=key[@version] per line")
+	cmd.Flags().Bool("mask", false, "Mask the passwords in the command's output")
+	cmd.Flags().Bool("inherit-env", false, "Pass pf's whole environment to the command")
+	cmd.Flags().String("store", "", "Store name")
+
+	// Flags after the command name belong to the command
+	cmd.Flags().SetInterspersed(false)
+
+	return cmd
+}
+
+// envRef is an environment variable to set from the store
+type envRef struct {
+	name    string
+	key     string
+	version int
+}
+
+func runExec(cmd *cobra.Command, args []string) error {
+	var refs []envRef
+
+	if envFile, _ := cmd.Flags().GetString("env-file"); envFile != "" {
+		fileRefs, err := readEnvFile(envFile)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, fileRefs...)
+	}
+
+	envFlags, _ := cmd.Flags().GetStringArray("env")
+	for _, value := range envFlags {
+		ref, err := parseEnvRef(value)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	}
+
+	if len(refs) == 0 {
+		return fmt.Errorf("no variables given, use --env or --env-file")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	s, _, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	inherit, _ := cmd.Flags().GetBool("inherit-env")
+	env, secrets, err := resolveEnv(s, refs, inherit)
+	if err != nil {
+		return err
+	}
+
+	child := exec.Command(args[0], args[1:]...)
+	child.Env = env
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	var maskers []*mask.Writer
+	if masked, _ := cmd.Flags().GetBool("mask"); masked {
+		stdout := mask.NewWriter(os.Stdout, secrets)
+		stderr := mask.NewWriter(os.Stderr, secrets)
+		child.Stdout, child.Stderr = stdout, stderr
+		maskers = append(maskers, stdout, stderr)
+	}
+
+	// Catch signals before starting so none is missed
+	signals := make(chan os.Signal, 8)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", args[0], err)
+	}
+
+	go func() {
+		for sig := range signals {
+			child.Process.Signal(sig)
+		}
+	}()
+
+	err = child.Wait()
+	for _, m := range maskers {
+		m.Close()
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		cmd.SilenceUsage = true
+		return &exitStatus{code: exitCode(exitErr)}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+
+	return nil
+}
+
+// execEnv lists the variables passed on to the command without --inherit-env.
+// Names ending in * are prefixes.
+var execEnv = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "COLORTERM", "LANG",
+	"LANGUAGE", "LC_*", "TZ", "TMPDIR", "XDG_RUNTIME_DIR",
+	// Windows
+	"SYSTEMROOT", "SYSTEMDRIVE", "WINDIR", "COMSPEC", "PATHEXT", "TEMP", "TMP",
+	"USERPROFILE", "APPDATA", "LOCALAPPDATA", "PROGRAMDATA",
+}
+
+// resolveEnv reads the referenced passwords and builds the child
+// environment from them and execEnv, or the whole environment if inherit is
+// set. It also returns the passwords, for masking.
+func resolveEnv(s *store.Store, refs []envRef, inherit bool) ([]string, []string, error) {
+	values := make(map[string]string)
+	var names []string
+	var secrets []string
+
+	for _, ref := range refs {
+		password, err := s.Get(ref.key, ref.version)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get password for %s: %w", ref.name, err)
+		}
+		if _, ok := values[ref.name]; !ok {
+			names = append(names, ref.name)
+		}
+		// Later references override earlier ones, --env over --env-file
+		values[ref.name] = password
+		secrets = append(secrets, password)
+	}
+
+	// The child must not inherit the key file passphrase
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := values[name]; ok || name == pfage.PassphraseEnv {
+			continue
+		}
+		if inherit || passedEnv(name) {
+			env = append(env, kv)
+		}
+	}
+	for _, name := range names {
+		env = append(env, name+"="+values[name])
+	}
+
+	return env, secrets, nil
+}
+
+// passedEnv reports whether a variable is in execEnv. Names are matched
+// case-insensitively on Windows.
+func passedEnv(name string) bool {
+	if runtime.GOOS == "windows" {
+		name = strings.ToUpper(name)
+	}
+	for _, allowed := range execEnv {
+		prefix, isPrefix := strings.CutSuffix(allowed, "*")
+		if name == allowed || isPrefix && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEnvRef parses NAME=key[@version]
+func parseEnvRef(value string) (envRef, error) {
+	name, ref, ok := strings.Cut(value, "=")
+	name = strings.TrimSpace(name)
+	ref = strings.TrimSpace(ref)
+	if !ok || name == "" || ref == "" {
+		return envRef{}, fmt.Errorf("invalid variable %q, expected NAME=key[@version]", value)
+	}
+
+	key, version := parseKeyVersion(ref)
+	return envRef{name: name, key: key, version: version}, nil
+}
+
+// parseKeyVersion splits key[@version], version 0 meaning the latest. Keys
+// may contain "@", as in git/github.com/bob@example.com, so only a positive
+// number after the last "@" is a version.
+func parseKeyVersion(ref string) (string, int) {
+	i := strings.LastIndex(ref, "@")
+	if i <= 0 {
+		return ref, 0
+	}
+
+	version, err := strconv.Atoi(ref[i+1:])
+	if err != nil || version < 1 || strings.ContainsAny(ref[i+1:], "+-") {
+		return ref, 0
+	}
+	return ref[:i], version
+}
+
+// readEnvFile reads NAME=key[@version] lines, ignoring blank lines and comments
+func readEnvFile(path string) ([]envRef, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer file.Close()
+
+	var refs []envRef
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		ref, err := parseEnvRef(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		refs = append(refs, ref)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	return refs, nil
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package cli
+
+import (
+	"os"
+	"os/exec"
+)
+
+// forwardedSignals are passed on to commands run by pf exec
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+// exitCode returns the exit code to propagate for a command that failed
+func exitCode(err *exec.ExitError) int {
+	return err.ExitCode()
+}
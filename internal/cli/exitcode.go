@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"pf/internal/store"
+)
+
+// Exit codes, documented in the README so scripts can branch on them
+const (
+	ExitError             = 1
+	ExitNotFound          = 2
+	ExitNoIdentity        = 3
+	ExitDecrypt           = 4
+	ExitVersionOutOfRange = 5
+	ExitCorruptEntry      = 6
+)
+
+// exitStatus is returned by commands that exit with the status of a command
+// they ran, such as pf exec. It is not printed.
+type exitStatus struct {
+	code int
+}
+
+func (e *exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// ExitCode returns the process exit code for an error returned by a command
+func ExitCode(err error) int {
+	var status *exitStatus
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &status):
+		return status.code
+	case errors.Is(err, store.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, store.ErrNoIdentity):
+		return ExitNoIdentity
+	case errors.Is(err, store.ErrDecrypt):
+		return ExitDecrypt
+	case errors.Is(err, store.ErrVersionOutOfRange):
+		return ExitVersionOutOfRange
+	case errors.Is(err, store.ErrCorruptEntry):
+		return ExitCorruptEntry
+	default:
+		return ExitError
+	}
+}
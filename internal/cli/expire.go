@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/config"
+	"pf/internal/store"
+)
+
+// NewExpireCommand creates the expire command
+func NewExpireCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "expire [key]",
+		Short:             "Set expiry date and rotation interval",
+		Long:              `Set or clear the expiry date and rotation interval of a password`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              runExpire,
+		ValidArgsFunction: passwordKeyCompletion,
+	}
+
+	cmd.Flags().String("store", "", "Store name")
+	cmd.Flags().String("expires", "", "Expiry date (YYYY-MM-DD)")
+	cmd.Flags().String("rotate-every", "", "Rotation interval (e.g. 90d, 12w)")
+	cmd.Flags().Bool("clear", false, "Clear expiry date and rotation interval")
+
+	return cmd
+}
+
+// NewExpiringCommand creates the expiring command
+func NewExpiringCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "expiring",
+		Short: "List passwords that expire or are due for rotation",
+		Long: `List passwords whose expiry date or rotation date falls within the given window.
+Rotation dates are counted from the timestamp of the latest version.`,
+		RunE:        runExpiring,
+		Annotations: map[string]string{outputAnnotation: "pf.expiring/v1"},
+	}
+
+	cmd.Flags().String("store", "", "Store name")
+	cmd.Flags().String("within", "30d", "Time window (e.g. 30d, 2w, 72h)")
+	cmd.Flags().Bool("exit-code", false, "Exit with a non-zero status if any password is listed")
+
+	return cmd
+}
+
+func runExpire(cmd *cobra.Command, args []string) error {
+	key := args[0]
+
+	// Load config
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	s, storeName, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	if clear, _ := cmd.Flags().GetBool("clear"); clear {
+		if err := s.SetExpiry(key, "", ""); err != nil {
+			return fmt.Errorf("failed to clear expiry: %w", err)
+		}
+		cmd.Printf("Expiry cleared for '%s' in store '%s'\n", key, storeName)
+		return nil
+	}
+
+	if !cmd.Flags().Changed("expires") && !cmd.Flags().Changed("rotate-every") {
+		return fmt.Errorf("specify --expires, --rotate-every or --clear")
+	}
+
+	if err := updateExpiry(cmd, s, key); err != nil {
+		return err
+	}
+
+	cmd.Printf("Expiry updated for '%s' in store '%s'\n", key, storeName)
+	return nil
+}
+
+func runExpiring(cmd *cobra.Command, args []string) error {
+	within, err := store.ParseInterval(cmd.Flag("within").Value.String())
+	if err != nil {
+		return err
+	}
+
+	// Load config
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	s, storeName, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	keys, err := s.List()
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	type expiring struct {
+		key      string
+		deadline time.Time
+		kind     string
+	}
+
+	now := time.Now()
+	var found []expiring
+	for _, key := range keys {
+		entry, err := s.Info(key)
+		if err != nil {
+			return fmt.Errorf("failed to read '%s': %w", key, err)
+		}
+		deadline, kind, ok := entry.Deadline()
+		if ok && deadline.Before(now.Add(within)) {
+			found = append(found, expiring{key: key, deadline: deadline, kind: kind})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].deadline.Before(found[j].deadline)
+	})
+
+	exitCode, _ := cmd.Flags().GetBool("exit-code")
+
+	if structuredOutput() {
+		list := expiringList{Store: storeName, Within: cmd.Flag("within").Value.String(), Entries: []expiringEntry{}}
+		for _, e := range found {
+			list.Entries = append(list.Entries, expiringEntry{
+				Key:      e.key,
+				Kind:     e.kind,
+				Deadline: e.deadline.Format(store.DateFormat),
+				Status:   strings.ToLower(deadlineStatus(e.deadline, e.kind, now)),
+			})
+		}
+		if err := writeOutput(cmd, list); err != nil {
+			return err
+		}
+		if exitCode && len(found) > 0 {
+			cmd.SilenceUsage = true
+			return reportedError{fmt.Errorf("%d password(s) expired or due for rotation", len(found))}
+		}
+		return nil
+	}
+
+	if len(found) == 0 {
+		cmd.Printf("No passwords in '%s' expire within %s\n", storeName, cmd.Flag("within").Value.String())
+		return nil
+	}
+
+	cmd.Printf("Passwords in '%s' expiring within %s:\n", storeName, cmd.Flag("within").Value.String())
+	for _, e := range found {
+		cmd.Printf("  %-8s %s - %s\n", deadlineStatus(e.deadline, e.kind, now), e.key, describeDeadline(e.deadline, e.kind))
+	}
+
+	if exitCode {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d password(s) expired or due for rotation", len(found))
+	}
+
+	return nil
+}
+
+// expiringList is the data of a pf.expiring/v1 document, soonest deadline first
+type expiringList struct {
+	Store   string          `json:"store" yaml:"store"`
+	Within  string          `json:"within" yaml:"within"`
+	Entries []expiringEntry `json:"entries" yaml:"entries"`
+}
+
+type expiringEntry struct {
+	Key      string `json:"key" yaml:"key"`
+	Kind     string `json:"kind" yaml:"kind"`
+	Deadline string `json:"deadline" yaml:"deadline"`
+	Status   string `json:"status" yaml:"status"`
+}
+
+// updateExpiry applies the --expires and --rotate-every flags to an entry,
+// keeping the current value of any flag that was not given
+func updateExpiry(cmd *cobra.Command, s *store.Store, key string) error {
+	entry, err := s.Info(key)
+	if err != nil {
+		return err
+	}
+
+	expires, rotateEvery := entry.Expires, entry.RotateEvery
+	if cmd.Flags().Changed("expires") {
+		expires = cmd.Flag("expires").Value.String()
+	}
+	if cmd.Flags().Changed("rotate-every") {
+		rotateEvery = cmd.Flag("rotate-every").Value.String()
+	}
+
+	if err := s.SetExpiry(key, expires, rotateEvery); err != nil {
+		return fmt.Errorf("failed to update expiry: %w", err)
+	}
+	return nil
+}
+
+// warnIfExpired prints a warning when an entry is expired or overdue for rotation
+func warnIfExpired(cmd *cobra.Command, entry *store.Entry) {
+	deadline, kind, ok := entry.Deadline()
+	if !ok || deadline.After(time.Now()) {
+		return
+	}
+	cmd.PrintErrf("Warning: '%s' %s\n", entry.Key, describeDeadline(deadline, kind))
+}
+
+func deadlineStatus(deadline time.Time, kind string, now time.Time) string {
+	switch {
+	case deadline.After(now):
+		return "DUE"
+	case kind == store.DeadlineRotate:
+		return "OVERDUE"
+	default:
+		return "EXPIRED"
+	}
+}
+
+func describeDeadline(deadline time.Time, kind string) string {
+	date := deadline.Format(store.DateFormat)
+	past := deadline.Before(time.Now())
+
+	switch {
+	case kind == store.DeadlineRotate && past:
+		return fmt.Sprintf("rotation overdue since %s", date)
+	case kind == store.DeadlineRotate:
+		return fmt.Sprintf("rotation due %s", date)
+	case past:
+		return fmt.Sprintf("expired on %s", date)
+	default:
+		return fmt.Sprintf("expires %s", date)
+	}
+}
@@ -3,12 +3,11 @@ package cli
 import (
 	"fmt"
 	"os"
-	"time"
 
-	"github.com/atotto/clipboard"
 	"github.com/spf13/cobra"
 
 	"pf/internal/config"
+	"pf/internal/keyring"
 	"pf/internal/store"
 )
 
@@ -21,18 +20,30 @@ func NewGetCommand() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE:  runGet,
 		ValidArgsFunction: passwordKeyCompletion,
+		Annotations: map[string]string{outputAnnotation: "pf.secret/v1"},
 	}
 
 	cmd.Flags().String("store", "", "Store name")
 	cmd.Flags().Bool("clip", false, "Copy password to clipboard")
-	cmd.Flags().Duration("clip-time", 45*time.Second, "Clipboard clearing time")
+	cmd.Flags().Duration("clip-time", 0, "Clipboard clearing time (default: clipboard_timeout)")
 	cmd.Flags().Int("version", 0, "Get specific version (0 = latest)")
+	cmd.Flags().Bool("qr", false, "Show the password, or the Wi-Fi network of entries with an ssid field, as a QR code")
+	cmd.Flags().Duration("qr-time", 0, "Clear the QR code after this long (default: clipboard_timeout)")
 
 	return cmd
 }
 
 func runGet(cmd *cobra.Command, args []string) error {
 	key := args[0]
+	clip, _ := cmd.Flags().GetBool("clip")
+	qr, _ := cmd.Flags().GetBool("qr")
+
+	if clip && qr {
+		return fmt.Errorf("--clip and --qr cannot be used together")
+	}
+	if (clip || qr) && structuredOutput() {
+		return fmt.Errorf("--clip and --qr cannot be used with --output %s", outputFormat)
+	}
 
 	// Load config
 	cfg, err := config.Load()
@@ -52,7 +63,7 @@ func runGet(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize store
-	s, err := store.New(storeConfig.Path, cfg.AgeKeyPath)
+	s, err := store.New(storeConfig.Path, keyring.New(cfg.IdentitySources(storeName)))
 	if err != nil {
 		return fmt.Errorf("failed to initialize store: %w", err)
 	}
@@ -64,20 +75,45 @@ func runGet(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get password: %w", err)
 	}
 
-	// Output or copy to clipboard
-	if clip, _ := cmd.Flags().GetBool("clip"); clip {
-		if err := clipboard.WriteAll(password); err != nil {
-			return fmt.Errorf("failed to copy to clipboard: %w", err)
+	// Warn about expired entries
+	entry, err := s.Info(key)
+	if err == nil {
+		warnIfExpired(cmd, entry)
+	}
+
+	if structuredOutput() {
+		if version == 0 && entry != nil {
+			version = len(entry.Versions)
+		}
+		_, fields := store.ParseFields(password)
+		return writeOutput(cmd, secret{
+			Store:    storeName,
+			Key:      key,
+			Version:  version,
+			Password: password,
+			Fields:   fields,
+		})
+	}
+
+	// Output, copy to clipboard or show as a QR code
+	if qr {
+		qrTime, _ := cmd.Flags().GetDuration("qr-time")
+		if !cmd.Flags().Changed("qr-time") {
+			qrTime = cfg.ClipboardTimeout
 		}
-		
+		payload, title := qrPayload(key, password)
+		return showQR(payload, title, qrTime)
+	}
+
+	if clip {
 		clipTime, _ := cmd.Flags().GetDuration("clip-time")
-		cmd.Printf("Password for '%s' copied to clipboard. Will clear in %s.\n", key, clipTime)
-		
-		// Clear clipboard after timeout
-		go func() {
-			time.Sleep(clipTime)
-			clipboard.WriteAll("")
-		}()
+		if !cmd.Flags().Changed("clip-time") {
+			clipTime = cfg.ClipboardTimeout
+		}
+		if err := copyToClipboard(cfg, password, clipTime); err != nil {
+			return err
+		}
+		printCopied(cmd, fmt.Sprintf("Password for '%s'", key), clipTime)
 	} else {
 		fmt.Fprint(os.Stdout, password)
 		if password[len(password)-1] != '\n' {
@@ -86,4 +122,14 @@ func runGet(cmd *cobra.Command, args []string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// secret is the data of a pf.secret/v1 document. Password is the whole
+// secret, fields are parsed from its lines after the first.
+type secret struct {
+	Store    string            `json:"store" yaml:"store"`
+	Key      string            `json:"key" yaml:"key"`
+	Version  int               `json:"version" yaml:"version"`
+	Password string            `json:"password" yaml:"password"`
+	Fields   map[string]string `json:"fields" yaml:"fields"`
+}
@@ -0,0 +1,350 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/audit"
+	"pf/internal/config"
+	"pf/internal/store"
+)
+
+// NewGitCredentialCommand creates the git-credential command
+func NewGitCredentialCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "git-credential [get|store|erase]",
+		Short: "Git credential helper",
+		Long: `Git credential helper speaking Git's credential protocol on stdin/stdout.
+
+Credentials are stored as the password followed by a "username: ..." field,
+under a key built from the git_credential.key template in the config
+(default: git/{host}/{username}). Without a username, get uses the only
+entry matching any username.
+
+Enable it with:
+
+  git config --global credential.helper '!pf git-credential'
+
+or link the pf binary as git-credential-pf somewhere in $PATH and use
+credential.helper=pf.`,
+		// Git may call helpers with operations they do not know, which
+		// must be ignored
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return cmd.Help()
+			}
+			return nil
+		},
+		Annotations: map[string]string{outputAnnotation: fixedOutput},
+	}
+
+	cmd.PersistentFlags().String("store", "", "Store name (default: git_credential.store or the default store)")
+
+	// Git shows helper errors to the user, usage would only add noise
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:          "get",
+			Short:        "Look up a credential",
+			Args:         cobra.NoArgs,
+			RunE:         runGitCredentialGet,
+			SilenceUsage: true,
+		},
+		&cobra.Command{
+			Use:          "store",
+			Short:        "Store a credential",
+			Args:         cobra.NoArgs,
+			RunE:         runGitCredentialStore,
+			SilenceUsage: true,
+		},
+		&cobra.Command{
+			Use:          "erase",
+			Short:        "Erase a credential",
+			Args:         cobra.NoArgs,
+			RunE:         runGitCredentialErase,
+			SilenceUsage: true,
+		},
+	)
+
+	return cmd
+}
+
+// gitCredential holds the attributes of a credential protocol request
+type gitCredential map[string]string
+
+func runGitCredentialGet(cmd *cobra.Command, args []string) error {
+	s, template, cred, err := openGitCredential(cmd)
+	if err != nil {
+		return err
+	}
+
+	key, err := findGitCredential(s, template, cred)
+	if err != nil {
+		return err
+	}
+
+	if key == "" {
+		s.Audit(audit.EventAccess, "", "Git credential lookup for "+cred.describe()+": not found")
+		return nil
+	}
+
+	// Get records the access
+	secret, err := s.Get(key, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get password: %w", err)
+	}
+
+	password, fields := store.ParseFields(secret)
+	username := fields["username"]
+	if username == "" {
+		username = cred["username"]
+	}
+	if username == "" && strings.HasSuffix(template, "{username}") {
+		username = path.Base(key)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	if username != "" {
+		fmt.Fprintf(out, "username=%s\n", username)
+	}
+	fmt.Fprintf(out, "password=%s\n", password)
+	return out.Flush()
+}
+
+func runGitCredentialStore(cmd *cobra.Command, args []string) error {
+	s, template, cred, err := openGitCredential(cmd)
+	if err != nil {
+		return err
+	}
+
+	if cred["password"] == "" {
+		return nil
+	}
+	if cred["username"] == "" && strings.Contains(template, "{username}") {
+		return fmt.Errorf("cannot store a credential without a username, the key template %q needs one", template)
+	}
+
+	key, err := expandGitKey(template, cred, nil)
+	if err != nil {
+		return err
+	}
+	fields := map[string]string{}
+	if cred["username"] != "" {
+		fields["username"] = cred["username"]
+	}
+
+	// Git stores after every successful use, only changes make a new version
+	secret := store.SetFields("", cred["password"], fields)
+	current, err := s.Get(key, 0)
+	switch {
+	case err == nil:
+		secret = store.SetFields(current, cred["password"], fields)
+		if secret == current || secret == current+"\n" {
+			return nil
+		}
+	case !errors.Is(err, store.ErrNotFound):
+		return fmt.Errorf("failed to get password: %w", err)
+	}
+
+	if err := s.Put(key, secret, "Stored by git credential helper"); err != nil {
+		return fmt.Errorf("failed to store password: %w", err)
+	}
+	return nil
+}
+
+func runGitCredentialErase(cmd *cobra.Command, args []string) error {
+	s, template, cred, err := openGitCredential(cmd)
+	if err != nil {
+		return err
+	}
+
+	key, err := findGitCredential(s, template, cred)
+	if err != nil || key == "" {
+		return err
+	}
+
+	// Only erase the credential Git rejected, not one updated since
+	if cred["password"] != "" {
+		current, err := s.Get(key, 0)
+		if err != nil {
+			return fmt.Errorf("failed to get password: %w", err)
+		}
+		if password, _ := store.ParseFields(current); password != cred["password"] {
+			return nil
+		}
+	}
+
+	if err := s.Delete(key); err != nil {
+		return fmt.Errorf("failed to delete password: %w", err)
+	}
+	return nil
+}
+
+// openGitCredential reads the request and opens the credential store
+func openGitCredential(cmd *cobra.Command) (*store.Store, string, gitCredential, error) {
+	cred, err := readGitCredential(os.Stdin)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if !cmd.Flags().Changed("store") && cfg.GitCredential.Store != "" {
+		cmd.Flags().Set("store", cfg.GitCredential.Store)
+	}
+
+	s, _, err := openStore(cmd, cfg)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	template := cfg.GitCredential.Key
+	if template == "" {
+		template = config.DefaultGitCredentialKey
+	}
+
+	return s, template, cred, nil
+}
+
+// readGitCredential reads name=value lines up to a blank line or EOF. A url
+// attribute fills in the attributes it contains.
+func readGitCredential(r io.Reader) (gitCredential, error) {
+	cred := gitCredential{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid credential attribute %q", line)
+		}
+		cred[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read credential: %w", err)
+	}
+
+	if raw := cred["url"]; raw != "" {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credential url: %w", err)
+		}
+		cred.setDefault("protocol", u.Scheme)
+		cred.setDefault("host", u.Host)
+		cred.setDefault("path", strings.TrimPrefix(u.Path, "/"))
+		cred.setDefault("username", u.User.Username())
+	}
+
+	if cred["host"] == "" {
+		return nil, fmt.Errorf("credential has no host")
+	}
+
+	return cred, nil
+}
+
+func (c gitCredential) setDefault(name, value string) {
+	if c[name] == "" && value != "" {
+		c[name] = value
+	}
+}
+
+// describe returns the credential's protocol, host and path for audit events
+func (c gitCredential) describe() string {
+	desc := c["host"]
+	if c["protocol"] != "" {
+		desc = c["protocol"] + "://" + desc
+	}
+	if c["path"] != "" {
+		desc += "/" + c["path"]
+	}
+	return desc
+}
+
+// findGitCredential returns the key of the entry for a credential, or ""
+// when there is none. Without a username, the only entry matching any
+// username is used.
+func findGitCredential(s *store.Store, template string, cred gitCredential) (string, error) {
+	if cred["username"] != "" || !strings.Contains(template, "{username}") {
+		key, err := expandGitKey(template, cred, nil)
+		if err != nil || !s.Exists(key) {
+			return "", err
+		}
+		return key, nil
+	}
+
+	pattern, err := expandGitKey(template, cred, escapeGlob)
+	if err != nil {
+		return "", err
+	}
+	keys, err := s.List()
+	if err != nil {
+		return "", fmt.Errorf("failed to list passwords: %w", err)
+	}
+
+	var found []string
+	for _, key := range keys {
+		if ok, _ := path.Match(pattern, key); ok {
+			found = append(found, key)
+		}
+	}
+
+	// Several matches leave it to Git to ask for the username
+	if len(found) != 1 {
+		return "", nil
+	}
+	return found[0], nil
+}
+
+// expandGitKey fills in a key template. Values are passed through quote if
+// set, and a missing username becomes a * wildcard. Empty path segments are
+// dropped, . and .. are rejected.
+func expandGitKey(template string, cred gitCredential, quote func(string) string) (string, error) {
+	value := func(name string) string {
+		if quote == nil {
+			return cred[name]
+		}
+		return quote(cred[name])
+	}
+
+	username := value("username")
+	if username == "" && quote != nil {
+		username = "*"
+	}
+
+	key := strings.NewReplacer(
+		"{protocol}", value("protocol"),
+		"{host}", value("host"),
+		"{path}", value("path"),
+		"{username}", username,
+	).Replace(template)
+
+	var parts []string
+	for _, part := range strings.Split(key, "/") {
+		switch part {
+		case "":
+			continue
+		case ".", "..":
+			return "", fmt.Errorf("invalid credential key %q", key)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// escapeGlob escapes path.Match metacharacters
+func escapeGlob(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`).Replace(s)
+}
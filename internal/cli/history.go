@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"pf/internal/config"
+	"pf/internal/keyring"
 	"pf/internal/store"
 )
 
@@ -19,6 +20,7 @@ func NewHistoryCommand() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE:  runHistory,
 		ValidArgsFunction: passwordKeyCompletion,
+		Annotations: map[string]string{outputAnnotation: "pf.history/v1"},
 	}
 
 	cmd.Flags().String("store", "", "Store name")
@@ -48,7 +50,7 @@ func runHistory(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize store
-	s, err := store.New(storeConfig.Path, cfg.AgeKeyPath)
+	s, err := store.New(storeConfig.Path, keyring.New(cfg.IdentitySources(storeName)))
 	if err != nil {
 		return fmt.Errorf("failed to initialize store: %w", err)
 	}
@@ -60,6 +62,20 @@ func runHistory(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get history: %w", err)
 	}
 
+	if structuredOutput() {
+		versions := []historyVersion{}
+		for i, version := range history {
+			versions = append(versions, historyVersion{
+				Version:   version.Version,
+				Timestamp: formatTime(version.Timestamp),
+				Author:    version.Author,
+				Message:   version.Message,
+				Current:   i == 0,
+			})
+		}
+		return writeOutput(cmd, historyList{Store: storeName, Key: key, Versions: versions})
+	}
+
 	if len(history) == 0 {
 		cmd.Printf("No history found for '%s'\n", key)
 		return nil
@@ -85,4 +101,19 @@ func runHistory(cmd *cobra.Command, args []string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// historyList is the data of a pf.history/v1 document, newest version first
+type historyList struct {
+	Store    string           `json:"store" yaml:"store"`
+	Key      string           `json:"key" yaml:"key"`
+	Versions []historyVersion `json:"versions" yaml:"versions"`
+}
+
+type historyVersion struct {
+	Version   int    `json:"version" yaml:"version"`
+	Timestamp string `json:"timestamp" yaml:"timestamp"`
+	Author    string `json:"author" yaml:"author"`
+	Message   string `json:"message" yaml:"message"`
+	Current   bool   `json:"current" yaml:"current"`
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/age"
+	"pf/internal/config"
+	"pf/internal/keyring"
+	"pf/internal/store"
+)
+
+func newAgeListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List identity sources and the stores each identity can decrypt",
+		Long: `List every configured identity source, the identities it provides and
+the stores each identity can decrypt.
+
+An identity matches a store when its recipient is in the store's .recipients
+file or its configured recipients. Identities without a known recipient, such
+as most plugin identities, are tried on the store's entries instead.`,
+		RunE: runAgeList,
+	}
+}
+
+func runAgeList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Open every store, recipients are enough for most identities
+	storeNames := make([]string, 0, len(cfg.Stores))
+	stores := make(map[string]*store.Store)
+	for name, storeConfig := range cfg.Stores {
+		s, err := store.New(storeConfig.Path, nil)
+		if err != nil {
+			cmd.Printf("Warning: failed to open store '%s': %v\n", name, err)
+			continue
+		}
+		storeNames = append(storeNames, name)
+		stores[name] = s
+	}
+	sort.Strings(storeNames)
+
+	covered := make(map[string]bool)
+	for _, source := range allIdentitySources(cfg) {
+		cmd.Println(keyring.Describe(source))
+
+		identities, err := keyring.LoadSource(source)
+		if err != nil {
+			cmd.Printf("  error: %v\n", err.(*keyring.SourceError).Err)
+			continue
+		}
+
+		for _, identity := range identities {
+			var decrypts []string
+			for _, name := range storeNames {
+				if identityDecrypts(identity, stores[name], cfg.Stores[name].Recipients) {
+					decrypts = append(decrypts, name)
+					covered[name] = true
+				}
+			}
+
+			label := identity.Recipient
+			if label == "" {
+				label = identity.Type + " identity"
+			}
+			if len(decrypts) == 0 {
+				cmd.Printf("  %s  (no stores)\n", label)
+			} else {
+				cmd.Printf("  %s  %s\n", label, strings.Join(decrypts, ", "))
+			}
+		}
+	}
+
+	var uncovered []string
+	for _, name := range storeNames {
+		if !covered[name] {
+			uncovered = append(uncovered, name)
+		}
+	}
+	if len(uncovered) > 0 {
+		cmd.Printf("\nNo identity can decrypt: %s\n", strings.Join(uncovered, ", "))
+	}
+
+	return nil
+}
+
+// identityDecrypts reports whether identity can decrypt the store, given its
+// configured recipients
+func identityDecrypts(identity *age.Identity, s *store.Store, recipients []string) bool {
+	if identity.Recipient != "" {
+		for _, list := range [][]string{s.Recipients(), recipients} {
+			for _, r := range list {
+				if age.SameRecipient(r, identity.Recipient) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	ok, _ := s.CanDecrypt(identity)
+	return ok
+}
+
+// useKeyFile makes path the age key file. The key file is only used when no
+// global identity sources are configured, otherwise it is added to them. A
+// previous key file that exists stays an identity source, stores may be
+// encrypted to it. It reports whether the identity sources changed.
+func useKeyFile(cfg *config.Config, path string) bool {
+	previous := cfg.AgeKeyPath
+	cfg.AgeKeyPath = path
+
+	changed := false
+	if len(cfg.Identities) == 0 {
+		if previous == "" || previous == path {
+			return false
+		}
+		if _, err := os.Stat(previous); err != nil {
+			return false
+		}
+		cfg.Identities = []config.IdentitySource{{File: previous}}
+		changed = true
+	}
+
+	source := config.IdentitySource{File: path}
+	if !slices.Contains(cfg.Identities, source) {
+		cfg.Identities = append(cfg.Identities, source)
+		changed = true
+	}
+	return changed
+}
+
+// allIdentitySources returns the global identity sources followed by the
+// sources of every store, without duplicates
+func allIdentitySources(cfg *config.Config) []config.IdentitySource {
+	sources := cfg.IdentitySources("")
+
+	storeNames := make([]string, 0, len(cfg.Stores))
+	for name := range cfg.Stores {
+		storeNames = append(storeNames, name)
+	}
+	sort.Strings(storeNames)
+	for _, name := range storeNames {
+		sources = append(sources, cfg.Stores[name].Identities...)
+	}
+
+	seen := make(map[config.IdentitySource]bool)
+	var unique []config.IdentitySource
+	for _, source := range sources {
+		if !seen[source] {
+			seen[source] = true
+			unique = append(unique, source)
+		}
+	}
+	return unique
+}
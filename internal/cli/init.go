@@ -25,6 +25,7 @@ func NewInitCommand() *cobra.Command {
 	cmd.Flags().String("store", "", "Store name (default: personal)")
 	cmd.Flags().String("age-key", "", "Path to age private key file")
 	cmd.Flags().String("recipient", "", "Age recipient public key")
+	cmd.Flags().Bool("passphrase", false, "Protect the generated key file with a passphrase")
 
 	return cmd
 }
@@ -47,6 +48,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 	// Get or generate age key
 	var keyPair *age.Key
+	passphrase := ""
 	ageKeyPath := cmd.Flag("age-key").Value.String()
 	recipient := cmd.Flag("recipient").Value.String()
 
@@ -59,17 +61,15 @@ func runInit(cmd *cobra.Command, args []string) error {
 		if len(identities) == 0 {
 			return fmt.Errorf("no identities found in key file")
 		}
-		// Use the first identity
-		// Note: we can't access the string representation directly from the interface
-		// We'll need to use the identity as-is
-		keyPair = &age.Key{
-			Identity: "loaded-from-file", // Placeholder - actual key is in identities
+		// Derive the recipient from the key unless one was given
+		if recipient == "" {
+			recipient = identityRecipient(identities)
 		}
-		// If recipient not provided, we can't derive it from the identity interface
 		if recipient == "" {
-			return fmt.Errorf("recipient must be provided when using existing key file")
-		} else {
-			keyPair.Recipient = recipient
+			return fmt.Errorf("cannot derive a recipient from %s, use --recipient", ageKeyPath)
+		}
+		keyPair = &age.Key{
+			Recipient: recipient,
 		}
 	} else if recipient != "" {
 		// Only recipient provided (for shared stores)
@@ -78,6 +78,13 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 	} else {
 		// Generate new key pair
+		if usePassphrase, _ := cmd.Flags().GetBool("passphrase"); usePassphrase {
+			var err error
+			if passphrase, err = readNewPassphrase(); err != nil {
+				return err
+			}
+		}
+
 		var err error
 		keyPair, err = age.GenerateKeyPair()
 		if err != nil {
@@ -85,8 +92,10 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 		cmd.Printf("Generated new age key pair:\n")
 		cmd.Printf("Recipient (public key): %s\n", keyPair.Recipient)
-		cmd.Printf("Identity (private key): %s\n", keyPair.Identity)
-		cmd.Printf("\nSave your private key securely!\n")
+		if passphrase == "" {
+			cmd.Printf("Identity (private key): %s\n", keyPair.Identity)
+			cmd.Printf("\nSave your private key securely!\n")
+		}
 	}
 
 	// Get store name
@@ -117,7 +126,13 @@ func runInit(cmd *cobra.Command, args []string) error {
 				Recipients: []string{keyPair.Recipient},
 			},
 		},
-		AgeKeyPath: filepath.Join(configDir, "age-key.txt"),
+		AgeKeyPath:       filepath.Join(configDir, "age-key.txt"),
+		ClipboardTimeout: config.DefaultClipboardTimeout,
+	}
+	if ageKeyPath != "" {
+		if absPath, err := filepath.Abs(ageKeyPath); err == nil {
+			cfg.AgeKeyPath = absPath
+		}
 	}
 
 	// Load existing config if it exists
@@ -133,6 +148,9 @@ func runInit(cmd *cobra.Command, args []string) error {
 			if existingCfg.DefaultStore == "" {
 				existingCfg.DefaultStore = storeName
 			}
+			if ageKeyPath != "" {
+				useKeyFile(existingCfg, cfg.AgeKeyPath)
+			}
 			cfg = existingCfg
 		}
 	}
@@ -150,7 +168,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 	if keyPair.Identity != "" && ageKeyPath == "" {
 		keyData := fmt.Sprintf("# Age private key for pf password manager\n# Public key: %s\n%s\n", 
 			keyPair.Recipient, keyPair.Identity)
-		if err := os.WriteFile(cfg.AgeKeyPath, []byte(keyData), 0600); err != nil {
+		if err := writeKeyFile(cfg.AgeKeyPath, keyData, passphrase); err != nil {
 			return fmt.Errorf("failed to save age key: %w", err)
 		}
 		cmd.Printf("\nPrivate key saved to: %s\n", cfg.AgeKeyPath)
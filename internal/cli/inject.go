@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/config"
+	"pf/internal/otp"
+	"pf/internal/store"
+)
+
+// NewInjectCommand creates the inject command
+func NewInjectCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inject",
+		Short: "Render a template with passwords substituted",
+		Long: `Render a Go text/template, replacing password references with their values.
+
+  {{ pf "db/prod" }}              the whole secret of the latest version
+  {{ pf "db/prod@2" }}            the secret of version 2
+  {{ pf "db/prod" "username" }}   a "username: ..." field of the entry
+  {{ pf "db/prod" "password" }}   the first line of an entry with fields
+  {{ otp "github" }}              a TOTP code from the entry's otpauth:// URI
+
+The output file is written with 0600 permissions. With --check, references
+are verified to exist without decrypting anything.`,
+		Example: `  pf inject -i app.conf.tmpl -o app.conf
+  pf inject -i app.conf.tmpl --check`,
+		Args:        cobra.NoArgs,
+		RunE:        runInject,
+		Annotations: map[string]string{outputAnnotation: fixedOutput},
+	}
+
+	cmd.Flags().StringP("input", "i", "", "Template file (default: stdin)")
+	cmd.Flags().StringP("out-file", "o", "", "Output file (default: stdout)")
+	cmd.Flags().Bool("check", false, "Only verify that every reference exists")
+	cmd.Flags().String("store", "", "Store name")
+
+	return cmd
+}
+
+func runInject(cmd *cobra.Command, args []string) error {
+	input, _ := cmd.Flags().GetString("input")
+	output, _ := cmd.Flags().GetString("out-file")
+	check, _ := cmd.Flags().GetBool("check")
+
+	var text []byte
+	var err error
+	name := "stdin"
+	if input == "" {
+		text, err = io.ReadAll(os.Stdin)
+	} else {
+		text, err = os.ReadFile(input)
+		name = filepath.Base(input)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	s, storeName, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	if check {
+		return checkTemplate(cmd, s, storeName, name, string(text))
+	}
+
+	funcs := template.FuncMap{
+		"pf": func(ref string, field ...string) (string, error) {
+			return resolveRef(s, ref, field)
+		},
+		"otp": func(ref string) (string, error) {
+			return resolveOTP(s, ref)
+		},
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Parse(string(text))
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	// Render fully before writing, so a failed reference leaves no partial output
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, nil); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	if output == "" {
+		_, err := os.Stdout.Write(rendered.Bytes())
+		return err
+	}
+
+	if err := writePrivateFile(output, rendered.Bytes()); err != nil {
+		return err
+	}
+
+	cmd.Printf("Rendered %s to %s\n", name, output)
+	return nil
+}
+
+// checkTemplate verifies every reference of a template without decrypting,
+// reporting all missing references at once
+func checkTemplate(cmd *cobra.Command, s *store.Store, storeName, name, text string) error {
+	var problems []string
+	count := 0
+
+	funcs := template.FuncMap{
+		"pf": func(ref string, field ...string) (string, error) {
+			count++
+			if err := checkRef(s, ref, field); err != nil {
+				problems = append(problems, err.Error())
+			}
+			return "", nil
+		},
+		"otp": func(ref string) (string, error) {
+			count++
+			if err := checkRef(s, ref, nil); err != nil {
+				problems = append(problems, err.Error())
+			}
+			return "", nil
+		},
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, nil); err != nil {
+		return fmt.Errorf("failed to check template: %w", err)
+	}
+
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			cmd.Printf("  %s\n", problem)
+		}
+		return fmt.Errorf("%d of %d references in %s do not resolve in store '%s'", len(problems), count, name, storeName)
+	}
+
+	cmd.Printf("All %d references in %s resolve in store '%s'\n", count, name, storeName)
+	return nil
+}
+
+// resolveRef returns the secret or field a reference points to. Secrets
+// are returned whole, multiline ones such as PEM keys included.
+func resolveRef(s *store.Store, ref string, field []string) (string, error) {
+	key, version, err := parseRef(ref, field)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := s.Get(key, version)
+	if err != nil {
+		return "", err
+	}
+
+	if len(field) == 0 {
+		return secret, nil
+	}
+
+	value, ok := store.Field(secret, field[0])
+	if !ok {
+		return "", fmt.Errorf("'%s' has no field '%s'", key, field[0])
+	}
+	return value, nil
+}
+
+// resolveOTP returns the current TOTP code of the otpauth:// URI in the
+// entry a reference points to
+func resolveOTP(s *store.Store, ref string) (string, error) {
+	key, version := parseKeyVersion(ref)
+	secret, err := s.Get(key, version)
+	if err != nil {
+		return "", err
+	}
+
+	otpKey, err := otp.Find(secret)
+	if err != nil {
+		return "", fmt.Errorf("'%s': %w", key, err)
+	}
+	return otpKey.Code(time.Now()), nil
+}
+
+// checkRef verifies that a reference's entry and version exist. Fields are
+// encrypted with the password, so they cannot be checked.
+func checkRef(s *store.Store, ref string, field []string) error {
+	key, version, err := parseRef(ref, field)
+	if err != nil {
+		return err
+	}
+
+	entry, err := s.Info(key)
+	if err != nil {
+		return err
+	}
+	if version > len(entry.Versions) {
+		return &store.VersionError{Key: key, Version: version, Latest: len(entry.Versions)}
+	}
+	return nil
+}
+
+// parseRef splits "key[@version]" and checks the field arguments
+func parseRef(ref string, field []string) (string, int, error) {
+	if len(field) > 1 {
+		return "", 0, fmt.Errorf("pf %q: expected at most one field name", ref)
+	}
+
+	key, version := parseKeyVersion(ref)
+	return key, version, nil
+}
+
+// writePrivateFile atomically writes data to a file only the user can read
+func writePrivateFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
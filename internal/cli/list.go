@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"pf/internal/config"
+	"pf/internal/keyring"
 	"pf/internal/store"
 )
 
@@ -18,6 +19,7 @@ func NewListCommand() *cobra.Command {
 		Long:  `List all password keys in the store`,
 		RunE:  runList,
 		Aliases: []string{"ls"},
+		Annotations: map[string]string{outputAnnotation: "pf.keys/v1"},
 	}
 
 	cmd.Flags().String("store", "", "Store name")
@@ -45,7 +47,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize store
-	s, err := store.New(storeConfig.Path, cfg.AgeKeyPath)
+	s, err := store.New(storeConfig.Path, keyring.New(cfg.IdentitySources(storeName)))
 	if err != nil {
 		return fmt.Errorf("failed to initialize store: %w", err)
 	}
@@ -56,6 +58,10 @@ func runList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list keys: %w", err)
 	}
 
+	if structuredOutput() {
+		return writeOutput(cmd, keyList{Store: storeName, Keys: append([]string{}, keys...)})
+	}
+
 	if len(keys) == 0 {
 		cmd.Printf("No passwords stored in '%s'\n", storeName)
 		return nil
@@ -76,6 +82,12 @@ func runList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// keyList is the data of a pf.keys/v1 document
+type keyList struct {
+	Store string   `json:"store" yaml:"store"`
+	Keys  []string `json:"keys" yaml:"keys"`
+}
+
 type treeNode struct {
 	children map[string]*treeNode
 	isLeaf   bool
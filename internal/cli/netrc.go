@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/config"
+	"pf/internal/store"
+)
+
+// NewNetrcCommand creates the netrc command
+func NewNetrcCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "netrc --prefix <prefix>",
+		Short: "Render passwords under a prefix as a netrc document",
+		Long: `Render the passwords under a prefix as a netrc document, on stdout or
+through a FIFO so no plaintext netrc file is kept on disk.
+
+For each entry, the machine is its "host: ..." field or the first part of
+the key after the prefix, and the login its "username: ..." field or the
+second part of the key. hosts/github.com and git/github.com/alice both work.
+
+With --fifo, the document is rendered once and served to every reader of
+the FIFO until pf is interrupted, then the FIFO is removed.`,
+		Example: `  pf netrc --prefix hosts/ > /dev/shm/netrc
+  pf netrc --prefix hosts/ --fifo "$XDG_RUNTIME_DIR/netrc" &
+  curl --netrc-file "$XDG_RUNTIME_DIR/netrc" https://example.com`,
+		Args:        cobra.NoArgs,
+		RunE:        runNetrc,
+		Annotations: map[string]string{outputAnnotation: fixedOutput},
+	}
+
+	cmd.Flags().String("prefix", "", "Key prefix of the entries to include")
+	cmd.Flags().String("fifo", "", "Serve the document through a FIFO created at this path")
+	cmd.Flags().String("store", "", "Store name")
+	cmd.MarkFlagRequired("prefix")
+
+	return cmd
+}
+
+func runNetrc(cmd *cobra.Command, args []string) error {
+	prefix, _ := cmd.Flags().GetString("prefix")
+	fifo, _ := cmd.Flags().GetString("fifo")
+
+	prefix = strings.Trim(prefix, "/") + "/"
+	if prefix == "/" {
+		return fmt.Errorf("prefix must not be empty")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	s, storeName, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	doc, count, err := renderNetrc(s, prefix)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("no passwords under '%s' in store '%s'", prefix, storeName)
+	}
+
+	if fifo == "" {
+		fmt.Fprint(os.Stdout, doc)
+		return nil
+	}
+
+	return serveFIFO(cmd, fifo, doc)
+}
+
+// renderNetrc renders the entries under prefix and returns their count
+func renderNetrc(s *store.Store, prefix string) (string, int, error) {
+	keys, err := s.List()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to list passwords: %w", err)
+	}
+
+	var doc strings.Builder
+	count := 0
+	for _, key := range keys {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+
+		secret, err := s.Get(key, 0)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to get password for '%s': %w", key, err)
+		}
+
+		password, fields := store.ParseFields(secret)
+		parts := strings.Split(rest, "/")
+
+		machine := fields["host"]
+		if machine == "" {
+			machine = parts[0]
+		}
+		login := fields["username"]
+		if login == "" && len(parts) == 2 {
+			login = parts[1]
+		}
+
+		fmt.Fprintf(&doc, "machine %s", netrcToken(machine))
+		if login != "" {
+			fmt.Fprintf(&doc, " login %s", netrcToken(login))
+		}
+		fmt.Fprintf(&doc, " password %s\n", netrcToken(password))
+		count++
+	}
+
+	return doc.String(), count, nil
+}
+
+// netrcToken quotes values containing whitespace or quotes
+func netrcToken(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\r\n\"\\") {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// serveFIFO writes doc to every reader of a FIFO at path until interrupted
+func serveFIFO(cmd *cobra.Command, path, doc string) error {
+	if err := makeFIFO(path); err != nil {
+		return fmt.Errorf("failed to create FIFO: %w", err)
+	}
+	defer os.Remove(path)
+
+	// Remove the FIFO on interruption too
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		os.Remove(path)
+		os.Exit(0)
+	}()
+
+	cmd.Printf("Serving netrc on %s, interrupt to stop\n", path)
+	for {
+		// Opening blocks until a reader opens the FIFO
+		file, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("failed to open FIFO: %w", err)
+		}
+
+		// Readers from now on get a fresh FIFO, so this one reaches EOF when
+		// closed rather than being served the document again
+		if err := renewFIFO(path); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to create FIFO: %w", err)
+		}
+
+		_, err = file.WriteString(doc)
+		file.Close()
+
+		// A reader closing early is not an error
+		if err != nil && !errors.Is(err, syscall.EPIPE) {
+			return fmt.Errorf("failed to write FIFO: %w", err)
+		}
+	}
+}
+
+// renewFIFO atomically replaces the FIFO at path with a new one
+func renewFIFO(path string) error {
+	tmp := path + ".new"
+	os.Remove(tmp)
+	if err := makeFIFO(tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package cli
+
+import "errors"
+
+// makeFIFO creates a FIFO only the user can access
+func makeFIFO(path string) error {
+	return errors.New("FIFOs are not supported on this platform")
+}
@@ -0,0 +1,10 @@
+//go:build unix
+
+package cli
+
+import "syscall"
+
+// makeFIFO creates a FIFO only the user can access
+func makeFIFO(path string) error {
+	return syscall.Mkfifo(path, 0600)
+}
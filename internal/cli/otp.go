@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/config"
+	"pf/internal/otp"
+)
+
+// NewOTPCommand creates the otp command
+func NewOTPCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "otp [key]",
+		Short: "Generate a one-time password",
+		Long: `Generate a TOTP code from the otpauth:// URI stored in an entry, either as
+a line of its own or as a field:
+
+  s3cret
+  otpauth: otpauth://totp/GitHub:alice?secret=JBSWY3DPEHPK3PXP&issuer=GitHub
+
+With --qr, the URI is shown as a QR code to set up an authenticator app.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              runOTP,
+		ValidArgsFunction: passwordKeyCompletion,
+		Annotations:       map[string]string{outputAnnotation: "pf.otp/v1"},
+	}
+
+	cmd.Flags().String("store", "", "Store name")
+	cmd.Flags().Bool("clip", false, "Copy the code to clipboard")
+	cmd.Flags().Duration("clip-time", 0, "Clipboard clearing time (default: clipboard_timeout)")
+	cmd.Flags().Bool("qr", false, "Show the otpauth:// URI as a QR code")
+	cmd.Flags().Duration("qr-time", 0, "Clear the QR code after this long (default: clipboard_timeout)")
+
+	return cmd
+}
+
+func runOTP(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	clip, _ := cmd.Flags().GetBool("clip")
+	qr, _ := cmd.Flags().GetBool("qr")
+
+	if clip && qr {
+		return fmt.Errorf("--clip and --qr cannot be used together")
+	}
+	if (clip || qr) && structuredOutput() {
+		return fmt.Errorf("--clip and --qr cannot be used with --output %s", outputFormat)
+	}
+
+	// Load config
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	s, storeName, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	secret, err := s.Get(key, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get password: %w", err)
+	}
+
+	otpKey, err := otp.Find(secret)
+	if err != nil {
+		return fmt.Errorf("'%s': %w", key, err)
+	}
+
+	if qr {
+		qrTime, _ := cmd.Flags().GetDuration("qr-time")
+		if !cmd.Flags().Changed("qr-time") {
+			qrTime = cfg.ClipboardTimeout
+		}
+		return showQR(otpKey.URI, fmt.Sprintf("OTP key for '%s'", key), qrTime)
+	}
+
+	now := time.Now()
+	code := otpKey.Code(now)
+	remaining := otpKey.Remaining(now)
+
+	if structuredOutput() {
+		return writeOutput(cmd, otpCode{
+			Store:     storeName,
+			Key:       key,
+			Code:      code,
+			Period:    int(otpKey.Period / time.Second),
+			Remaining: int(remaining / time.Second),
+		})
+	}
+
+	if clip {
+		clipTime, _ := cmd.Flags().GetDuration("clip-time")
+		if !cmd.Flags().Changed("clip-time") {
+			clipTime = cfg.ClipboardTimeout
+		}
+		if err := copyToClipboard(cfg, code, clipTime); err != nil {
+			return err
+		}
+		printCopied(cmd, fmt.Sprintf("OTP code for '%s' (valid for %s)", key, remaining), clipTime)
+		return nil
+	}
+
+	fmt.Fprintln(os.Stdout, code)
+	return nil
+}
+
+// otpCode is the data of a pf.otp/v1 document. Remaining is the number of
+// seconds the code stays valid.
+type otpCode struct {
+	Store     string `json:"store" yaml:"store"`
+	Key       string `json:"key" yaml:"key"`
+	Code      string `json:"code" yaml:"code"`
+	Period    int    `json:"period" yaml:"period"`
+	Remaining int    `json:"remaining" yaml:"remaining"`
+}
@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"pf/internal/store"
+)
+
+// Output formats of the global --output flag
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+// outputFormat is the format selected with --output
+var outputFormat = OutputText
+
+// outputAnnotation names the schema a command writes its result in, it is
+// inherited by subcommands. Commands without one report their messages as a
+// pf.result/v1 document.
+const outputAnnotation = "pf.output"
+
+// fixedOutput marks commands whose output format is fixed, such as
+// credential helpers and interactive commands, which reject --output
+const fixedOutput = "fixed"
+
+// Schemas of documents that are not specific to one command
+const (
+	resultSchema = "pf.result/v1"
+	errorSchema  = "pf.error/v1"
+)
+
+// document is the envelope of every structured document. Fields are only
+// added to a schema version, anything else gets a new version.
+type document struct {
+	Schema string       `json:"schema" yaml:"schema"`
+	Data   any          `json:"data,omitempty" yaml:"data,omitempty"`
+	Error  *errorObject `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// result is the data of a pf.result/v1 document
+type result struct {
+	Command  string   `json:"command" yaml:"command"`
+	Messages []string `json:"messages" yaml:"messages"`
+}
+
+// errorObject is the error of a pf.error/v1 document
+type errorObject struct {
+	Code    int    `json:"code" yaml:"code"`
+	Type    string `json:"type" yaml:"type"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// structuredOutput reports whether a json or yaml document was requested
+func structuredOutput() bool {
+	return outputFormat != OutputText
+}
+
+// outputSchema returns the schema annotation of cmd or of a parent command,
+// pf.result/v1 when neither has one
+func outputSchema(cmd *cobra.Command) string {
+	for c := cmd; c != nil; c = c.Parent() {
+		if schema, ok := c.Annotations[outputAnnotation]; ok {
+			return schema
+		}
+		// Generated commands print scripts and help text
+		if c.Name() == "help" || c.Name() == "completion" || strings.HasPrefix(c.Name(), "__complete") {
+			return fixedOutput
+		}
+	}
+	return resultSchema
+}
+
+// setupOutput validates --output before a command runs. Messages of
+// commands without a schema are captured for their pf.result/v1 document.
+func setupOutput(cmd *cobra.Command) error {
+	// A local flag of the same name would take the value instead, only the
+	// deprecated --output <file> aliases may
+	if f := cmd.LocalNonPersistentFlags().Lookup("output"); f != nil && f.Deprecated == "" {
+		return fmt.Errorf("'%s' shadows the global --output flag", cmd.CommandPath())
+	}
+
+	switch outputFormat {
+	case OutputText:
+		return nil
+	case OutputJSON, OutputYAML:
+	default:
+		format := outputFormat
+		outputFormat = OutputText
+		return fmt.Errorf("unknown output format '%s' (expected text, json or yaml)", format)
+	}
+
+	cmd.SilenceUsage = true
+
+	switch outputSchema(cmd) {
+	case fixedOutput:
+		return fmt.Errorf("'%s' does not support --output", cmd.CommandPath())
+	case resultSchema:
+		cmd.SetOut(&bytes.Buffer{})
+	}
+	return nil
+}
+
+// finishOutput writes the pf.result/v1 document of a command without a
+// schema after it succeeded
+func finishOutput(cmd *cobra.Command) error {
+	if !structuredOutput() || outputSchema(cmd) != resultSchema {
+		return nil
+	}
+
+	buf, ok := cmd.OutOrStdout().(*bytes.Buffer)
+	if !ok {
+		return nil
+	}
+
+	messages := []string{}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			messages = append(messages, line)
+		}
+	}
+
+	name := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	return writeDocument(os.Stdout, document{Schema: resultSchema, Data: result{Command: name, Messages: messages}})
+}
+
+// writeOutput writes data as the document of the command's schema
+func writeOutput(cmd *cobra.Command, data any) error {
+	return writeDocument(os.Stdout, document{Schema: outputSchema(cmd), Data: data})
+}
+
+func writeDocument(w io.Writer, doc document) error {
+	if outputFormat == OutputYAML {
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return encoder.Close()
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// addOutputFileAlias keeps the --output <file> flag of commands that had one
+// before the global --output flag, as a deprecated alias of --out-file. On
+// these commands it takes the place of the global flag.
+func addOutputFileAlias(cmd *cobra.Command) {
+	cmd.Flags().Var(outFileAlias{cmd}, "output", cmd.Flags().Lookup("out-file").Usage)
+	cmd.Flags().MarkDeprecated("output", "use --out-file instead")
+}
+
+// outFileAlias sets --out-file, so it counts as given for required flags
+type outFileAlias struct {
+	cmd *cobra.Command
+}
+
+func (a outFileAlias) String() string     { return a.cmd.Flags().Lookup("out-file").Value.String() }
+func (a outFileAlias) Set(v string) error { return a.cmd.Flags().Set("out-file", v) }
+func (a outFileAlias) Type() string       { return "string" }
+
+// reportedError is an error a command already reported in its document, only
+// the exit code is left to set
+type reportedError struct {
+	error
+}
+
+func (e reportedError) Unwrap() error { return e.error }
+
+// PrintError reports an error returned by a command: as a pf.error/v1
+// document on stdout with --output json or yaml, on stderr otherwise
+func PrintError(err error) {
+	var status *exitStatus
+	if errors.As(err, &status) {
+		return
+	}
+	if !structuredOutput() {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return
+	}
+	if errors.As(err, &reportedError{}) {
+		return
+	}
+
+	doc := document{Schema: errorSchema, Error: &errorObject{
+		Code:    ExitCode(err),
+		Type:    errorType(err),
+		Message: err.Error(),
+	}}
+	if writeDocument(os.Stdout, doc) != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+}
+
+// errorType returns the stable name of the exit code of err
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, store.ErrNoIdentity):
+		return "no_identity"
+	case errors.Is(err, store.ErrDecrypt):
+		return "decrypt"
+	case errors.Is(err, store.ErrVersionOutOfRange):
+		return "version_out_of_range"
+	case errors.Is(err, store.ErrCorruptEntry):
+		return "corrupt_entry"
+	default:
+		return "error"
+	}
+}
+
+// formatTime formats a Unix timestamp for structured output
+func formatTime(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
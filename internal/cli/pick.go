@@ -0,0 +1,341 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/config"
+	"pf/internal/fuzzy"
+	"pf/internal/keyring"
+	"pf/internal/store"
+	"pf/internal/tui"
+)
+
+// pickHeight is the number of items the picker shows at once
+const pickHeight = 10
+
+// errPickCancelled is returned when the picker is left without a selection
+var errPickCancelled = errors.New("no entry selected")
+
+// NewPickCommand creates the pick command
+func NewPickCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pick [query]",
+		Short: "Fuzzy-find a password and copy it to the clipboard",
+		Long: `Pick an entry from all configured stores with an inline fuzzy finder and
+copy its password to the clipboard, or print its key with --print-key.
+
+Type to filter, ↑↓ or Ctrl-P/Ctrl-N to move, Enter to copy the password,
+Tab to choose a field of the entry instead, Esc to cancel.
+
+The picker draws on the terminal rather than stdout, so it can be bound to a
+shell key or run in a tmux popup, and its output captured.`,
+		Example: `  pf pick
+  pf pick db --field username
+  pf get "$(pf pick --print-key --store work)" --store work
+  bind -x '"\C-p": pf pick'`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE:         runPick,
+		Annotations:  map[string]string{outputAnnotation: fixedOutput},
+	}
+
+	cmd.Flags().String("store", "", "Only pick from this store (default: all stores)")
+	cmd.Flags().String("field", "", "Copy this field instead of the password")
+	cmd.Flags().Bool("print-key", false, "Print the selected key on stdout instead of copying")
+
+	return cmd
+}
+
+// pickItem is an entry of a store
+type pickItem struct {
+	storeName string
+	key       string
+}
+
+func runPick(cmd *cobra.Command, args []string) error {
+	storeName, _ := cmd.Flags().GetString("store")
+	field, _ := cmd.Flags().GetString("field")
+	printKey, _ := cmd.Flags().GetBool("print-key")
+
+	if printKey && field != "" {
+		return fmt.Errorf("--field and --print-key cannot be used together")
+	}
+
+	query := ""
+	if len(args) > 0 {
+		query = args[0]
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	stores, items, err := pickItems(cfg, storeName)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("no passwords to pick from")
+	}
+
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = item.key
+	}
+
+	// The store column is only needed when picking across stores
+	var labels func(int) string
+	if len(stores) > 1 {
+		labels = func(i int) string { return items[i].storeName }
+	}
+
+	previews := make(map[int]string)
+	preview := func(i int) string {
+		if _, ok := previews[i]; !ok {
+			previews[i] = pickPreview(stores[items[i].storeName], items[i])
+		}
+		return previews[i]
+	}
+
+	index, chooseField, err := pickFrom(query, keys, labels, preview, !printKey && field == "")
+	if err != nil {
+		return err
+	}
+	item := items[index]
+
+	if printKey {
+		fmt.Fprintln(os.Stdout, item.key)
+		return nil
+	}
+
+	s := stores[item.storeName]
+	secret, err := s.Get(item.key, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get password: %w", err)
+	}
+
+	if chooseField {
+		if field, err = pickField(secret); err != nil {
+			return err
+		}
+	}
+
+	// Entries with fields copy their password, others the whole secret
+	value, what := secret, fmt.Sprintf("Password for '%s'", item.key)
+	password, fields := store.ParseFields(secret)
+	switch {
+	case field != "" && field != store.FieldPassword:
+		var ok bool
+		if value, ok = store.Field(secret, field); !ok {
+			return fmt.Errorf("'%s' has no field '%s'", item.key, field)
+		}
+		what = fmt.Sprintf("Field '%s' of '%s'", field, item.key)
+	case field != "" || len(fields) > 0:
+		value = password
+	}
+
+	if err := copyToClipboard(cfg, value, cfg.ClipboardTimeout); err != nil {
+		return err
+	}
+	printCopied(cmd, what, cfg.ClipboardTimeout)
+
+	return nil
+}
+
+// pickItems opens the stores to pick from, all of them unless storeName is
+// set, and lists their entries
+func pickItems(cfg *config.Config, storeName string) (map[string]*store.Store, []pickItem, error) {
+	var names []string
+	if storeName != "" {
+		if _, ok := cfg.Stores[storeName]; !ok {
+			return nil, nil, fmt.Errorf("store '%s' not found", storeName)
+		}
+		names = []string{storeName}
+	} else {
+		for name := range cfg.Stores {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	stores := make(map[string]*store.Store)
+	var items []pickItem
+	for _, name := range names {
+		s, err := store.New(cfg.Stores[name].Path, keyring.New(cfg.IdentitySources(name)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize store '%s': %w", name, err)
+		}
+		s.SetAuthor(os.Getenv("USER"))
+
+		keys, err := s.List()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list passwords in store '%s': %w", name, err)
+		}
+
+		stores[name] = s
+		for _, key := range keys {
+			items = append(items, pickItem{storeName: name, key: key})
+		}
+	}
+
+	return stores, items, nil
+}
+
+// pickPreview describes an entry without decrypting it
+func pickPreview(s *store.Store, item pickItem) string {
+	entry, err := s.Info(item.key)
+	if err != nil || len(entry.Versions) == 0 {
+		return item.storeName
+	}
+
+	latest := entry.Versions[len(entry.Versions)-1]
+	parts := []string{item.storeName, fmt.Sprintf("v%d", latest.Version)}
+
+	updated := "updated " + time.Unix(latest.Timestamp, 0).Format("2006-01-02 15:04")
+	if latest.Author != "" {
+		updated += " by " + latest.Author
+	}
+	parts = append(parts, updated)
+
+	if latest.Message != "" {
+		parts = append(parts, latest.Message)
+	}
+	if deadline, kind, ok := entry.Deadline(); ok {
+		parts = append(parts, describeDeadline(deadline, kind))
+	}
+
+	return strings.Join(parts, " · ")
+}
+
+// pickField lets the user choose the password or a field of secret
+func pickField(secret string) (string, error) {
+	_, fields := store.ParseFields(secret)
+	if len(fields) == 0 {
+		return store.FieldPassword, nil
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	names = append([]string{store.FieldPassword}, names...)
+
+	index, _, err := pickFrom("", names, nil, nil, false)
+	if err != nil {
+		return "", err
+	}
+	return names[index], nil
+}
+
+// pickFrom runs an inline fuzzy finder over items and returns the index of
+// the selected item, and whether it was selected with Tab when allowed.
+// labels and preview are optional, labels are shown right of the items and
+// the preview of the selected item below them.
+func pickFrom(query string, items []string, labels, preview func(int) string, allowTab bool) (int, bool, error) {
+	t, err := tui.Open(false)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to open terminal: %w", err)
+	}
+	defer t.Close()
+
+	p := &picker{items: items, labels: labels, preview: preview}
+	p.query.Set(query)
+	p.filter()
+
+	for {
+		p.draw(t)
+
+		select {
+		case k, ok := <-t.Keys():
+			if !ok {
+				return 0, false, errPickCancelled
+			}
+
+			switch {
+			case k.Type == tui.KeyEsc || k.IsCtrl('c') || k.IsCtrl('g'):
+				return 0, false, errPickCancelled
+			case k.Type == tui.KeyEnter || (k.Type == tui.KeyTab && allowTab):
+				if len(p.results) == 0 {
+					continue
+				}
+				return p.results[p.list.Cursor].Index, k.Type == tui.KeyTab, nil
+			case p.list.HandleKey(k, len(p.results), pickHeight):
+			case p.query.HandleKey(k):
+				p.filter()
+			}
+		case <-t.Resized():
+		}
+	}
+}
+
+// picker is the state of pickFrom
+type picker struct {
+	items   []string
+	labels  func(int) string
+	preview func(int) string
+
+	query   tui.Input
+	results []fuzzy.Result
+	list    tui.List
+}
+
+func (p *picker) filter() {
+	p.results = fuzzy.Filter(p.query.Value(), p.items)
+	p.list = tui.List{}
+}
+
+func (p *picker) draw(t *tui.Terminal) {
+	width, height := t.Size()
+	rows := min(pickHeight, len(p.items), max(height-3, 1))
+
+	count := fmt.Sprintf("  %d/%d", len(p.results), len(p.items))
+	input := p.query.View(width-tui.Width(count)-3, true)
+	lines := []string{tui.Accent("> ") + input + tui.Dim(count)}
+
+	start, end := p.list.Window(len(p.results), rows)
+	for i := start; i < end; i++ {
+		result := p.results[i]
+
+		label := ""
+		if p.labels != nil {
+			label = " " + p.labels(result.Index) + " "
+		}
+		name := tui.Fit("  "+p.items[result.Index], width-tui.Width(label))
+
+		// Shift match positions past the margin
+		positions := make([]int, len(result.Positions))
+		for n, pos := range result.Positions {
+			positions[n] = pos + 2
+		}
+
+		line := tui.Highlight(name, positions, func(s string) string {
+			return tui.Bold(tui.Accent(s))
+		}) + tui.Dim(label)
+		if i == p.list.Cursor {
+			line = tui.Reverse(line)
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) < rows+1 {
+		lines = append(lines, "")
+	}
+
+	if p.preview != nil {
+		text := ""
+		if len(p.results) > 0 {
+			text = p.preview(p.results[p.list.Cursor].Index)
+		}
+		lines = append(lines, tui.Dim(tui.Fit("  "+text, width)))
+	}
+
+	t.Draw(lines)
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/spf13/cobra"
 	"golang.org/x/crypto/ssh/terminal"
 
+	"pf/internal/breach"
 	"pf/internal/config"
+	"pf/internal/keyring"
 	"pf/internal/store"
 )
 
@@ -28,6 +30,8 @@ func NewPutCommand() *cobra.Command {
 	cmd.Flags().String("store", "", "Store name")
 	cmd.Flags().Bool("multiline", false, "Enable multiline input")
 	cmd.Flags().String("message", "", "Version message")
+	cmd.Flags().String("expires", "", "Expiry date (YYYY-MM-DD)")
+	cmd.Flags().String("rotate-every", "", "Rotation interval (e.g. 90d, 12w)")
 
 	return cmd
 }
@@ -53,10 +57,11 @@ func runPut(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize store
-	s, err := store.New(storeConfig.Path, cfg.AgeKeyPath)
+	s, err := store.New(storeConfig.Path, keyring.New(cfg.IdentitySources(storeName)))
 	if err != nil {
 		return fmt.Errorf("failed to initialize store: %w", err)
 	}
+	s.SetAuthor(os.Getenv("USER"))
 
 	// Get password input
 	var password string
@@ -87,19 +92,19 @@ func runPut(cmd *cobra.Command, args []string) error {
 			}
 		} else {
 			// Interactive input
-			fmt.Print("Enter password: ")
+			fmt.Fprint(os.Stderr, "Enter password: ")
 			bytePassword, err := terminal.ReadPassword(int(syscall.Stdin))
 			if err != nil {
 				return fmt.Errorf("failed to read password: %w", err)
 			}
-			fmt.Println()
+			fmt.Fprintln(os.Stderr)
 			
-			fmt.Print("Confirm password: ")
+			fmt.Fprint(os.Stderr, "Confirm password: ")
 			byteConfirm, err := terminal.ReadPassword(int(syscall.Stdin))
 			if err != nil {
 				return fmt.Errorf("failed to read password confirmation: %w", err)
 			}
-			fmt.Println()
+			fmt.Fprintln(os.Stderr)
 			
 			if string(bytePassword) != string(byteConfirm) {
 				return fmt.Errorf("passwords do not match")
@@ -113,14 +118,56 @@ func runPut(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("password cannot be empty")
 	}
 
+	// Warn when the password is known to be breached, fields aside
+	if cfg.BreachDataset != "" {
+		first, _ := store.ParseFields(password)
+		warnIfBreached(cmd, cfg.BreachDataset, first)
+	}
+
 	// Get version message
 	message := cmd.Flag("message").Value.String()
 
+	// Check expiry settings before storing anything
+	setExpiry := cmd.Flags().Changed("expires") || cmd.Flags().Changed("rotate-every")
+	if setExpiry {
+		expires, _ := cmd.Flags().GetString("expires")
+		rotateEvery, _ := cmd.Flags().GetString("rotate-every")
+		if err := store.ValidateExpiry(expires, rotateEvery); err != nil {
+			return err
+		}
+	}
+
 	// Store password
 	if err := s.Put(key, password, message); err != nil {
 		return fmt.Errorf("failed to store password: %w", err)
 	}
 
+	// Update expiry settings if requested
+	if setExpiry {
+		if err := updateExpiry(cmd, s, key); err != nil {
+			return err
+		}
+	}
+
 	cmd.Printf("Password stored for '%s' in store '%s'\n", key, storeName)
 	return nil
-}
\ No newline at end of file
+}
+
+// warnIfBreached prints a warning when password appears in the breach dataset
+func warnIfBreached(cmd *cobra.Command, datasetPath, password string) {
+	dataset, err := breach.Open(datasetPath)
+	if err != nil {
+		cmd.PrintErrf("Warning: could not check breach dataset: %v\n", err)
+		return
+	}
+	defer dataset.Close()
+
+	found, err := dataset.Contains(password)
+	if err != nil {
+		cmd.PrintErrf("Warning: could not check breach dataset: %v\n", err)
+		return
+	}
+	if found {
+		cmd.PrintErrln("Warning: this password appears in the breach dataset, consider choosing another one")
+	}
+}
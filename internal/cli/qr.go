@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pf/internal/qrterm"
+	"pf/internal/store"
+	"pf/internal/tui"
+)
+
+// qrPayload returns the text to encode for a secret: a Wi-Fi network for
+// entries with an ssid field, the password otherwise. It also returns a
+// description of the payload.
+func qrPayload(key, secret string) (string, string) {
+	password, fields := store.ParseFields(secret)
+	if ssid := fields["ssid"]; ssid != "" {
+		return wifiPayload(ssid, password, fields), fmt.Sprintf("Wi-Fi network '%s'", ssid)
+	}
+	if len(fields) > 0 {
+		return password, fmt.Sprintf("Password for '%s'", key)
+	}
+	return strings.TrimSuffix(secret, "\n"), fmt.Sprintf("Password for '%s'", key)
+}
+
+// wifiPayload formats a Wi-Fi network as phones scan it:
+// WIFI:T:<security>;S:<ssid>;P:<password>;H:true;;
+// The security field is WPA (default), WEP or nopass.
+func wifiPayload(ssid, password string, fields map[string]string) string {
+	security := strings.ToUpper(fields["security"])
+	switch security {
+	case "":
+		security = "WPA"
+	case "NONE", "OPEN", "NOPASS":
+		security = "nopass"
+	}
+
+	escape := strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`).Replace
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "WIFI:T:%s;S:%s;", security, escape(ssid))
+	if security != "nopass" {
+		fmt.Fprintf(&b, "P:%s;", escape(password))
+	}
+	if hidden := strings.ToLower(fields["hidden"]); hidden == "true" || hidden == "yes" {
+		b.WriteString("H:true;")
+	}
+	b.WriteString(";")
+	return b.String()
+}
+
+// showQR shows a QR code of payload on the alternate screen until a key is
+// pressed or timeout passes, so it is not left in the scrollback
+func showQR(payload, title string, timeout time.Duration) error {
+	var code strings.Builder
+	if err := qrterm.Render(&code, payload); err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimSuffix(code.String(), "\n"), "\n")
+
+	t, err := tui.Open(true)
+	if errors.Is(err, tui.ErrNoTerminal) {
+		return fmt.Errorf("--qr needs a terminal")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open terminal: %w", err)
+	}
+	defer t.Close()
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	until := time.Now().Add(timeout)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		width, height := t.Size()
+
+		hint := "Press any key to clear"
+		if timeout > 0 {
+			remaining := time.Until(until).Round(time.Second)
+			hint = fmt.Sprintf("Press any key to clear, clears in %s", max(remaining, 0))
+		}
+
+		screen := []string{" " + tui.Bold(title), ""}
+		if tui.Width(lines[0]) > width || len(lines)+4 > height {
+			needed := fmt.Sprintf(" The terminal is too small for the QR code (%dx%d needed)", tui.Width(lines[0]), len(lines)+4)
+			screen = append(screen, tui.Warn(tui.Fit(needed, width)))
+		} else {
+			screen = append(screen, lines...)
+		}
+		screen = append(screen, "", tui.Dim(" "+hint))
+		t.Draw(screen[:min(len(screen), height)])
+
+		select {
+		case <-t.Keys():
+			return nil
+		case <-deadline:
+			return nil
+		case <-ticker.C:
+		case <-t.Resized():
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"pf/internal/age"
+	"pf/internal/config"
+	"pf/internal/store"
+)
+
+// rotationState records an in-progress key rotation so it can be resumed
+type rotationState struct {
+	OldRecipients []string `yaml:"old_recipients"`
+	NewRecipient  string   `yaml:"new_recipient"`
+	NewKeyPath    string   `yaml:"new_key_path"`
+	ArchivePath   string   `yaml:"archive_path"`
+	Encrypted     bool     `yaml:"encrypted"`
+	Started       int64    `yaml:"started"`
+}
+
+func newAgeRotateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate",
+		Short: "Replace the age key in every store",
+		Long: `Generate a new age key and replace the current one everywhere it is used.
+
+The old recipient is replaced by the new one in the .recipients file of every
+configured store, and all entries encrypted for the old key are re-encrypted.
+The old key stays usable for decryption until the rotation completes, then it
+is archived under archive/ next to the key file.
+
+If the rotation is interrupted, run the command again to resume it.`,
+		RunE: runAgeRotate,
+	}
+}
+
+func runAgeRotate(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	statePath := cfg.AgeKeyPath + ".rotate"
+	state, err := loadRotationState(statePath)
+	if err != nil {
+		return err
+	}
+
+	var newKeyData, passphrase string
+	if state == nil {
+		state, newKeyData, passphrase, err = startRotation(cmd, cfg, statePath)
+		if err != nil {
+			return err
+		}
+	} else {
+		cmd.Printf("Resuming key rotation started %s\n",
+			time.Unix(state.Started, 0).Format("2006-01-02 15:04:05"))
+		newKeyData, passphrase, err = readNewKey(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	newIdentities, err := age.ParseIdentities([]byte(newKeyData))
+	if err != nil {
+		return fmt.Errorf("failed to load new key: %w", err)
+	}
+
+	// During the transition the key file holds both the new and the old key
+	transition, err := loadKeyFile(cfg.AgeKeyPath, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to load age key: %w", err)
+	}
+	identities := age.AgeIdentities(append(newIdentities, transition...))
+
+	// Replace the recipient and re-encrypt, store by store
+	storeNames := make([]string, 0, len(cfg.Stores))
+	for name := range cfg.Stores {
+		storeNames = append(storeNames, name)
+	}
+	sort.Strings(storeNames)
+
+	configChanged := false
+	for _, name := range storeNames {
+		storeConfig := cfg.Stores[name]
+
+		s, err := store.New(storeConfig.Path, nil)
+		if err != nil {
+			return fmt.Errorf("failed to open store '%s': %w", name, err)
+		}
+		s.SetIdentities(identities)
+
+		if _, err := s.ReplaceRecipients(state.OldRecipients, state.NewRecipient); err != nil {
+			return fmt.Errorf("failed to update recipients of store '%s': %w", name, err)
+		}
+		if recipients, changed := replaceRecipient(storeConfig.Recipients, state.OldRecipients, state.NewRecipient); changed {
+			storeConfig.Recipients = recipients
+			cfg.Stores[name] = storeConfig
+			configChanged = true
+		}
+
+		if !hasRecipient(s.Recipients(), state.NewRecipient) {
+			continue
+		}
+
+		keys, err := s.List()
+		if err != nil {
+			return fmt.Errorf("failed to list store '%s': %w", name, err)
+		}
+
+		total := 0
+		for _, key := range keys {
+			count, err := s.Reencrypt(key, age.AgeIdentities(newIdentities))
+			if err != nil {
+				return fmt.Errorf("failed to re-encrypt '%s' in store '%s': %w", key, name, err)
+			}
+			total += count
+		}
+		cmd.Printf("Store '%s': %d versions re-encrypted\n", name, total)
+	}
+
+	if configChanged {
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+	}
+
+	// Drop the old key from the key file, it is kept in the archive
+	if err := writeKeyFile(cfg.AgeKeyPath, newKeyData, passphrase); err != nil {
+		return fmt.Errorf("failed to save new key: %w", err)
+	}
+	os.Remove(state.NewKeyPath)
+	if err := os.Remove(statePath); err != nil {
+		return fmt.Errorf("failed to remove rotation state: %w", err)
+	}
+
+	cmd.Printf("\nKey rotated. New recipient: %s\n", state.NewRecipient)
+	cmd.Printf("Old key archived to: %s\n", state.ArchivePath)
+	cmd.Println("If the agent is running, load the new key with 'pf agent lock && pf agent add'.")
+	return nil
+}
+
+// startRotation generates the new key, archives the old one and records
+// the rotation state
+func startRotation(cmd *cobra.Command, cfg *config.Config, statePath string) (*rotationState, string, string, error) {
+	oldFile, err := os.ReadFile(cfg.AgeKeyPath)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to read age key: %w", err)
+	}
+
+	oldKeyData, err := age.ReadIdentityFile(cfg.AgeKeyPath)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to load age key: %w", err)
+	}
+	oldIdentities, err := age.ParseIdentities(oldKeyData)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("only age key files can be rotated: %w", err)
+	}
+
+	var oldRecipients []string
+	for _, identity := range oldIdentities {
+		if identity.Type != age.TypeX25519 {
+			return nil, "", "", fmt.Errorf("only age X25519 keys can be rotated, found a %s identity", identity.Type)
+		}
+		oldRecipients = append(oldRecipients, identity.Recipient)
+	}
+
+	// Keep the passphrase protection of the current key
+	passphrase := ""
+	if age.IsEncrypted(oldFile) {
+		cmd.Println("Choose a passphrase for the new key.")
+		if passphrase, err = readNewPassphrase(); err != nil {
+			return nil, "", "", err
+		}
+	}
+
+	keyPair, err := age.GenerateKeyPair()
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to generate age key: %w", err)
+	}
+	newKeyData := fmt.Sprintf("# Age private key for pf password manager\n# Public key: %s\n%s\n",
+		keyPair.Recipient, keyPair.Identity)
+
+	// Archive the old key file as-is before touching anything
+	archiveDir := filepath.Join(filepath.Dir(cfg.AgeKeyPath), "archive")
+	if err := os.MkdirAll(archiveDir, 0700); err != nil {
+		return nil, "", "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	archivePath, err := archiveKeyFile(archiveDir, oldFile)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to archive old key: %w", err)
+	}
+
+	state := &rotationState{
+		OldRecipients: oldRecipients,
+		NewRecipient:  keyPair.Recipient,
+		NewKeyPath:    cfg.AgeKeyPath + ".new",
+		ArchivePath:   archivePath,
+		Encrypted:     passphrase != "",
+		Started:       time.Now().Unix(),
+	}
+
+	if err := writeKeyFile(state.NewKeyPath, newKeyData, passphrase); err != nil {
+		return nil, "", "", fmt.Errorf("failed to save new key: %w", err)
+	}
+	if err := saveRotationState(statePath, state); err != nil {
+		return nil, "", "", err
+	}
+
+	transitionData := newKeyData + "\n# Previous key, kept until the rotation completes\n" + string(oldKeyData)
+	if err := writeKeyFile(cfg.AgeKeyPath, transitionData, passphrase); err != nil {
+		return nil, "", "", fmt.Errorf("failed to save age key: %w", err)
+	}
+
+	cmd.Printf("Generated new age key: %s\n", keyPair.Recipient)
+	return state, newKeyData, passphrase, nil
+}
+
+// archiveKeyFile writes data to a new timestamped file in dir, never
+// overwriting an earlier archive
+func archiveKeyFile(dir string, data []byte) (string, error) {
+	name := "age-key-" + time.Now().Format("20060102-150405")
+	for i := 0; ; i++ {
+		path := filepath.Join(dir, name+".txt")
+		if i > 0 {
+			path = filepath.Join(dir, fmt.Sprintf("%s-%d.txt", name, i))
+		}
+
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := file.Write(data); err != nil {
+			file.Close()
+			return "", err
+		}
+		return path, file.Close()
+	}
+}
+
+// readNewKey reads the new key of an interrupted rotation
+func readNewKey(state *rotationState) (string, string, error) {
+	data, err := os.ReadFile(state.NewKeyPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read new key: %w", err)
+	}
+	if !state.Encrypted {
+		return string(data), "", nil
+	}
+
+	passphrase, err := age.ReadPassphrase("Enter passphrase for the new key: ")
+	if err != nil {
+		return "", "", err
+	}
+	plaintext, err := age.DecryptIdentityFile(data, passphrase)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(plaintext), passphrase, nil
+}
+
+// loadKeyFile loads a key file, trying passphrase before prompting
+func loadKeyFile(path, passphrase string) ([]*age.Identity, error) {
+	if passphrase != "" {
+		if data, err := os.ReadFile(path); err == nil && age.IsEncrypted(data) {
+			if plaintext, err := age.DecryptIdentityFile(data, passphrase); err == nil {
+				return age.ParseIdentities(plaintext)
+			}
+		}
+	}
+	return age.LoadIdentityFile(path)
+}
+
+func loadRotationState(path string) (*rotationState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read rotation state: %w", err)
+	}
+
+	var state rotationState
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse rotation state %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+func saveRotationState(path string, state *rotationState) error {
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rotation state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save rotation state: %w", err)
+	}
+	return nil
+}
+
+// replaceRecipient replaces the recipients matching old with newRecipient
+func replaceRecipient(recipients, old []string, newRecipient string) ([]string, bool) {
+	changed := false
+	var result []string
+	for _, r := range recipients {
+		for _, o := range old {
+			if age.SameRecipient(r, o) {
+				r, changed = newRecipient, true
+				break
+			}
+		}
+		if r == newRecipient && hasRecipient(result, newRecipient) {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result, changed
+}
+
+func hasRecipient(recipients []string, recipient string) bool {
+	for _, r := range recipients {
+		if age.SameRecipient(r, recipient) {
+			return true
+		}
+	}
+	return false
+}
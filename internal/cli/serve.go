@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/agent"
+	"pf/internal/api"
+	"pf/internal/config"
+)
+
+// NewServeCommand creates the serve command
+func NewServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a local HTTP/JSON API on a Unix socket",
+		Long: `Serve list, get, put, history and otp for a store as an HTTP/JSON API on a
+Unix socket, so tools can read secrets without running pf for every lookup.
+
+Clients authenticate with a bearer token created by 'pf serve token add' and
+can be limited to keys under given paths. Requests are audited under the client
+name, rejected ones too.
+
+  GET /v1/keys
+  GET /v1/secrets/<key>[?version=N]
+  PUT /v1/secrets/<key>    {"password": "...", "message": "..."}
+  GET /v1/history/<key>
+  GET /v1/otp/<key>        a TOTP code from the entry's otpauth:// URI`,
+		RunE:        runServe,
+		Annotations: map[string]string{outputAnnotation: fixedOutput},
+	}
+
+	cmd.Flags().String("socket", "", "Socket path (default: $XDG_RUNTIME_DIR/pf-api.sock or ~/.pf/api.sock)")
+	cmd.Flags().String("store", "", "Store name")
+
+	cmd.AddCommand(newServeTokenCommand())
+
+	return cmd
+}
+
+func newServeTokenCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "token",
+		Short:       "Manage API client tokens",
+		Annotations: map[string]string{outputAnnotation: resultSchema},
+	}
+
+	add := &cobra.Command{
+		Use:         "add [name]",
+		Short:       "Create a client and print its token",
+		Args:        cobra.ExactArgs(1),
+		RunE:        runServeTokenAdd,
+		Annotations: map[string]string{outputAnnotation: "pf.api-token/v1"},
+	}
+	add.Flags().StringSlice("scope", nil, "Key or key path the client may access, db covers db/prod (repeatable, default: all keys)")
+
+	cmd.AddCommand(
+		add,
+		&cobra.Command{
+			Use:         "list",
+			Short:       "List API clients",
+			RunE:        runServeTokenList,
+			Annotations: map[string]string{outputAnnotation: "pf.api-clients/v1"},
+		},
+		&cobra.Command{
+			Use:   "remove [name]",
+			Short: "Remove an API client",
+			Args:  cobra.ExactArgs(1),
+			RunE:  runServeTokenRemove,
+		},
+	)
+
+	return cmd
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if len(cfg.APIClients) == 0 {
+		return fmt.Errorf("no API clients configured, create one with 'pf serve token add <name>'")
+	}
+
+	s, storeName, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Load identities up front rather than prompting during a request
+	if err := s.LoadIdentities(); err != nil {
+		cmd.Printf("Warning: %v\nGet requests will fail until identities are available.\n", err)
+	}
+
+	socketPath, _ := cmd.Flags().GetString("socket")
+	if socketPath == "" {
+		socketPath = apiSocketPath()
+	}
+
+	listener, err := agent.Listen(socketPath)
+	if errors.Is(err, agent.ErrSocketInUse) {
+		return fmt.Errorf("pf serve is already running on %s, use --socket to start another server", socketPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           api.NewServer(s, cfg.APIClients),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Shut down cleanly on termination, closing the listener removes the socket
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		<-signals
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	cmd.Printf("Serving store '%s' on %s\n", storeName, socketPath)
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// apiSocketPath returns the default API socket path
+func apiSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "pf-api.sock")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".pf", "api.sock")
+}
+
+func runServeTokenAdd(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	for _, client := range cfg.APIClients {
+		if client.Name == name {
+			return fmt.Errorf("API client '%s' already exists", name)
+		}
+	}
+
+	token, err := api.GenerateToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	scopes, _ := cmd.Flags().GetStringSlice("scope")
+	cfg.APIClients = append(cfg.APIClients, config.APIClient{
+		Name:      name,
+		TokenHash: api.HashToken(token),
+		Scopes:    scopes,
+	})
+
+	if err := saveConfig(cfg); err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return writeOutput(cmd, apiClient{Name: name, Scopes: append([]string{}, scopes...), Token: token})
+	}
+
+	cmd.Printf("API client '%s' created. Save the token now, it cannot be shown again.\n", name)
+	cmd.Println("Restart pf serve for the client to be accepted.")
+	fmt.Fprintln(os.Stdout, token)
+	return nil
+}
+
+func runServeTokenList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if structuredOutput() {
+		clients := []apiClient{}
+		for _, client := range cfg.APIClients {
+			clients = append(clients, apiClient{Name: client.Name, Scopes: append([]string{}, client.Scopes...)})
+		}
+		return writeOutput(cmd, clients)
+	}
+
+	if len(cfg.APIClients) == 0 {
+		cmd.Println("No API clients configured")
+		return nil
+	}
+
+	for _, client := range cfg.APIClients {
+		scopes := "all keys"
+		if len(client.Scopes) > 0 {
+			scopes = strings.Join(client.Scopes, ", ")
+		}
+		cmd.Printf("%-20s %s\n", client.Name, scopes)
+	}
+
+	return nil
+}
+
+// apiClient is an element of a pf.api-clients/v1 document and the data of a
+// pf.api-token/v1 document, the only one with the token. No scopes means all
+// keys.
+type apiClient struct {
+	Name   string   `json:"name" yaml:"name"`
+	Scopes []string `json:"scopes" yaml:"scopes"`
+	Token  string   `json:"token,omitempty" yaml:"token,omitempty"`
+}
+
+func runServeTokenRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var clients []config.APIClient
+	for _, client := range cfg.APIClients {
+		if client.Name != name {
+			clients = append(clients, client)
+		}
+	}
+	if len(clients) == len(cfg.APIClients) {
+		return fmt.Errorf("API client '%s' not found", name)
+	}
+
+	cfg.APIClients = clients
+	if err := saveConfig(cfg); err != nil {
+		return err
+	}
+
+	cmd.Printf("API client '%s' removed\n", name)
+	return nil
+}
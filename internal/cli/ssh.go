@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	pfage "pf/internal/age"
+	"pf/internal/config"
+)
+
+// NewSSHAddCommand creates the ssh-add command
+func NewSSHAddCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ssh-add [key]",
+		Short: "Add an SSH private key to the running ssh-agent",
+		Long: `Decrypt an SSH private key stored as a multiline entry and add it to the
+ssh-agent at $SSH_AUTH_SOCK, without writing it to disk. Passphrase-protected
+keys prompt for their passphrase.`,
+		Example: `  pf ssh-add ssh/server --lifetime 1h`,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runSSHAdd,
+	}
+
+	cmd.Flags().Duration("lifetime", 0, "Remove the key from the agent after this long (default: never)")
+	cmd.Flags().Bool("confirm", false, "Have the agent confirm each use of the key")
+	cmd.Flags().String("store", "", "Store name")
+
+	return cmd
+}
+
+// NewSSHKeygenCommand creates the ssh-keygen command
+func NewSSHKeygenCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ssh-keygen [key]",
+		Short: "Generate an ed25519 SSH key pair",
+		Long: `Generate an ed25519 SSH key pair, store the private key under the given
+key and print the public key in authorized_keys format.`,
+		Example:     `  pf ssh-keygen ssh/server >> ~/.ssh/server.pub`,
+		Args:        cobra.ExactArgs(1),
+		RunE:        runSSHKeygen,
+		Annotations: map[string]string{outputAnnotation: "pf.ssh-key/v1"},
+	}
+
+	cmd.Flags().StringP("comment", "C", "", "Key comment (default: the key name)")
+	cmd.Flags().BoolP("force", "f", false, "Replace an existing entry with a new version")
+	cmd.Flags().String("store", "", "Store name")
+
+	return cmd
+}
+
+func runSSHAdd(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	lifetime, _ := cmd.Flags().GetDuration("lifetime")
+	confirm, _ := cmd.Flags().GetBool("confirm")
+
+	if lifetime < 0 || (lifetime > 0 && lifetime < time.Second) {
+		return fmt.Errorf("lifetime must be at least 1s")
+	}
+
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return fmt.Errorf("SSH_AUTH_SOCK is not set, is ssh-agent running?")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	s, _, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	secret, err := s.Get(key, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get password: %w", err)
+	}
+
+	privateKey, err := parseSSHPrivateKey(key, []byte(secret))
+	if err != nil {
+		return err
+	}
+
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to use SSH key '%s': %w", key, err)
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ssh-agent: %w", err)
+	}
+	defer conn.Close()
+
+	err = agent.NewClient(conn).Add(agent.AddedKey{
+		PrivateKey:       privateKey,
+		Comment:          key,
+		LifetimeSecs:     uint32(lifetime / time.Second),
+		ConfirmBeforeUse: confirm,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add key to ssh-agent: %w", err)
+	}
+
+	cmd.Printf("Identity added: %s (%s)\n", key, ssh.FingerprintSHA256(signer.PublicKey()))
+	if lifetime > 0 {
+		cmd.Printf("Lifetime set to %s\n", lifetime)
+	}
+	return nil
+}
+
+// parseSSHPrivateKey parses a PEM-encoded private key, prompting for the
+// passphrase of protected keys
+func parseSSHPrivateKey(key string, pemBytes []byte) (interface{}, error) {
+	privateKey, err := ssh.ParseRawPrivateKey(pemBytes)
+
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		passphrase, err := pfage.ReadPassphrase(fmt.Sprintf("Enter passphrase for SSH key %s: ", key))
+		if err != nil {
+			return nil, fmt.Errorf("SSH key '%s' is passphrase-protected: %w", key, err)
+		}
+		privateKey, err = ssh.ParseRawPrivateKeyWithPassphrase(pemBytes, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt SSH key '%s': %w", key, err)
+		}
+		return privateKey, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not an SSH private key: %w", key, err)
+	}
+
+	return privateKey, nil
+}
+
+func runSSHKeygen(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	comment, _ := cmd.Flags().GetString("comment")
+	force, _ := cmd.Flags().GetBool("force")
+
+	if comment == "" {
+		comment = key
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	s, storeName, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	if s.Exists(key) && !force {
+		return fmt.Errorf("'%s' already exists, use --force to replace it", key)
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(privateKey, comment)
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	if err := s.Put(key, string(pem.EncodeToMemory(block)), "Generated ed25519 SSH key"); err != nil {
+		return fmt.Errorf("failed to store private key: %w", err)
+	}
+
+	authorized := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(sshPublicKey)), "\n")
+	if structuredOutput() {
+		return writeOutput(cmd, sshKey{Store: storeName, Key: key, PublicKey: authorized + " " + comment})
+	}
+
+	cmd.Printf("Private key stored for '%s' in store '%s'\n", key, storeName)
+	fmt.Fprintf(os.Stdout, "%s %s\n", authorized, comment)
+	return nil
+}
+
+// sshKey is the data of a pf.ssh-key/v1 document
+type sshKey struct {
+	Store     string `json:"store" yaml:"store"`
+	Key       string `json:"key" yaml:"key"`
+	PublicKey string `json:"public_key" yaml:"public_key"`
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -32,9 +33,10 @@ func NewStoreCommand() *cobra.Command {
 
 func newStoreListCommand() *cobra.Command {
 	return &cobra.Command{
-		Use:   "list",
-		Short: "List all stores",
-		RunE:  runStoreList,
+		Use:         "list",
+		Short:       "List all stores",
+		RunE:        runStoreList,
+		Annotations: map[string]string{outputAnnotation: "pf.stores/v1"},
 	}
 }
 
@@ -78,6 +80,22 @@ func runStoreList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	if structuredOutput() {
+		list := storeList{Default: cfg.DefaultStore, Stores: []storeInfo{}}
+		for name, store := range cfg.Stores {
+			list.Stores = append(list.Stores, storeInfo{
+				Name:       name,
+				Path:       store.Path,
+				Default:    name == cfg.DefaultStore,
+				Recipients: append([]string{}, store.Recipients...),
+			})
+		}
+		sort.Slice(list.Stores, func(i, j int) bool {
+			return list.Stores[i].Name < list.Stores[j].Name
+		})
+		return writeOutput(cmd, list)
+	}
+
 	if len(cfg.Stores) == 0 {
 		cmd.Println("No stores configured")
 		return nil
@@ -99,6 +117,19 @@ func runStoreList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// storeList is the data of a pf.stores/v1 document
+type storeList struct {
+	Default string      `json:"default" yaml:"default"`
+	Stores  []storeInfo `json:"stores" yaml:"stores"`
+}
+
+type storeInfo struct {
+	Name       string   `json:"name" yaml:"name"`
+	Path       string   `json:"path" yaml:"path"`
+	Default    bool     `json:"default" yaml:"default"`
+	Recipients []string `json:"recipients" yaml:"recipients"`
+}
+
 func runStoreAdd(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
@@ -0,0 +1,962 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"pf/internal/config"
+	"pf/internal/fuzzy"
+	"pf/internal/keyring"
+	"pf/internal/store"
+	"pf/internal/tui"
+)
+
+// NewUICommand creates the ui command
+func NewUICommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ui",
+		Short: "Browse and manage passwords in a full-screen terminal UI",
+		Long: `Browse a store as a collapsible tree with its version history, filter
+keys as you type, and reveal, copy, put, roll back and delete passwords.
+
+  ↑↓ jk        move                  /        fuzzy filter, Esc clears
+  ←→ hl        collapse / expand     tab      history pane
+  r            reveal / hide         c        copy to clipboard
+  p            put a new version     n        new entry
+  b            roll back (history)   d        delete
+  s            switch store          R        reload
+  q            quit
+
+Copied passwords are cleared from the clipboard after clipboard_timeout.`,
+		Args:        cobra.NoArgs,
+		RunE:        runUI,
+		Annotations: map[string]string{outputAnnotation: fixedOutput},
+	}
+
+	cmd.Flags().String("store", "", "Store name")
+
+	return cmd
+}
+
+func runUI(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	s, storeName, err := openStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Passphrase prompts need the terminal, load identities before taking it
+	identityErr := s.LoadIdentities()
+
+	t, err := tui.Open(true)
+	if err != nil {
+		return fmt.Errorf("failed to open terminal: %w", err)
+	}
+	defer t.Close()
+
+	app := &uiApp{
+		cfg:       cfg,
+		term:      t,
+		storeName: storeName,
+		store:     s,
+		expanded:  make(map[string]bool),
+	}
+	app.reload()
+	if identityErr != nil {
+		app.setError(identityErr)
+	}
+
+	return app.run()
+}
+
+// uiApp is the state of pf ui
+type uiApp struct {
+	cfg  *config.Config
+	term *tui.Terminal
+
+	storeName string
+	store     *store.Store
+	keys      []string
+	tree      *uiNode
+	expanded  map[string]bool
+
+	rows []uiRow
+	list tui.List
+
+	filter    tui.Input
+	filtering bool
+
+	// History of the selected entry
+	historyKey   string
+	history      []store.Version
+	entry        *store.Entry
+	versions     tui.List
+	focusHistory bool
+
+	// Password revealed for a key and version
+	revealKey     string
+	revealVersion int
+	revealed      string
+
+	// Active prompt, drawn on the status line
+	prompt *uiPrompt
+
+	// Store names while switching stores
+	stores    []string
+	storeList tui.List
+
+	status    string
+	statusErr bool
+
+	// When a copied password is cleared from the clipboard
+	clipUntil time.Time
+
+	quit bool
+}
+
+// uiRow is a line of the tree or of the filtered keys
+type uiRow struct {
+	// path is the key of entries and the prefix of directories
+	path      string
+	name      string
+	depth     int
+	dir       bool
+	positions []int
+}
+
+// uiNode is a directory or entry of the key tree, or both
+type uiNode struct {
+	children map[string]*uiNode
+	entry    bool
+}
+
+// uiPrompt reads a line, or a yes/no answer when confirm is set
+type uiPrompt struct {
+	label   string
+	input   tui.Input
+	confirm bool
+	submit  func(string)
+}
+
+func (a *uiApp) run() error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for !a.quit {
+		a.sync()
+		a.draw()
+
+		select {
+		case key, ok := <-a.term.Keys():
+			if !ok {
+				a.quit = true
+				break
+			}
+			a.handleKey(key)
+		case <-a.term.Resized():
+		case <-ticker.C:
+			// The clearing process clears the clipboard itself
+			if !a.clipUntil.IsZero() && time.Now().After(a.clipUntil) {
+				a.clipUntil = time.Time{}
+			}
+		}
+	}
+
+	// Don't leave a copied password behind
+	if !a.clipUntil.IsZero() {
+		clearClipboard(a.cfg)
+	}
+	return nil
+}
+
+func (a *uiApp) setStatus(format string, args ...interface{}) {
+	a.status = fmt.Sprintf(format, args...)
+	a.statusErr = false
+}
+
+func (a *uiApp) setError(err error) {
+	// Only the first line fits the status line
+	a.status, _, _ = strings.Cut(err.Error(), "\n")
+	a.statusErr = true
+}
+
+// reload reads the keys of the store and rebuilds the tree
+func (a *uiApp) reload() {
+	keys, err := a.store.List()
+	if err != nil {
+		a.setError(err)
+		return
+	}
+
+	a.keys = keys
+	a.tree = buildUITree(keys)
+	a.historyKey = ""
+	a.buildRows()
+}
+
+func buildUITree(keys []string) *uiNode {
+	root := &uiNode{children: make(map[string]*uiNode)}
+	for _, key := range keys {
+		node := root
+		for _, part := range strings.Split(key, "/") {
+			child, ok := node.children[part]
+			if !ok {
+				child = &uiNode{children: make(map[string]*uiNode)}
+				node.children[part] = child
+			}
+			node = child
+		}
+		node.entry = true
+	}
+	return root
+}
+
+// buildRows lists the filtered keys, or the expanded tree without a filter,
+// keeping the cursor on the same row where possible
+func (a *uiApp) buildRows() {
+	selected := ""
+	if row := a.selectedRow(); row != nil {
+		selected = row.path
+	}
+
+	a.rows = nil
+	if query := a.filter.Value(); strings.TrimSpace(query) != "" {
+		for _, result := range fuzzy.Filter(query, a.keys) {
+			key := a.keys[result.Index]
+			a.rows = append(a.rows, uiRow{path: key, name: key, positions: result.Positions})
+		}
+		a.list = tui.List{}
+		return
+	}
+
+	a.rows = a.flatten(a.tree, "", 0)
+	for i, row := range a.rows {
+		if row.path == selected {
+			a.list.Cursor = i
+		}
+	}
+	a.list.Clamp(len(a.rows))
+}
+
+func (a *uiApp) flatten(node *uiNode, prefix string, depth int) []uiRow {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rows []uiRow
+	for _, name := range names {
+		child := node.children[name]
+		path := prefix + name
+		if len(child.children) > 0 {
+			rows = append(rows, uiRow{path: path, name: name, depth: depth, dir: true})
+			if a.expanded[path] {
+				rows = append(rows, a.flatten(child, path+"/", depth+1)...)
+			}
+		}
+		if child.entry {
+			rows = append(rows, uiRow{path: path, name: name, depth: depth})
+		}
+	}
+	return rows
+}
+
+func (a *uiApp) selectedRow() *uiRow {
+	if a.list.Cursor < 0 || a.list.Cursor >= len(a.rows) {
+		return nil
+	}
+	return &a.rows[a.list.Cursor]
+}
+
+// selectedKey returns the key of the selected entry, "" on a directory
+func (a *uiApp) selectedKey() string {
+	if row := a.selectedRow(); row != nil && !row.dir {
+		return row.path
+	}
+	return ""
+}
+
+// selectedVersion returns the version selected in the history pane, or the
+// latest version
+func (a *uiApp) selectedVersion() int {
+	if len(a.history) == 0 {
+		return 0
+	}
+	if a.focusHistory {
+		return a.history[a.versions.Cursor].Version
+	}
+	return a.history[0].Version
+}
+
+// sync loads the history of the selected entry when the selection changes
+func (a *uiApp) sync() {
+	key := a.selectedKey()
+	if key == a.historyKey {
+		return
+	}
+
+	a.historyKey = key
+	a.history, a.entry = nil, nil
+	a.versions = tui.List{}
+	a.focusHistory = false
+	if key == "" {
+		return
+	}
+
+	history, err := a.store.GetHistory(key, 0)
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	a.history = history
+
+	if entry, err := a.store.Info(key); err == nil {
+		a.entry = entry
+	}
+}
+
+func (a *uiApp) bodyHeight() int {
+	_, height := a.term.Size()
+	return max(1, height-3)
+}
+
+func (a *uiApp) handleKey(k tui.Key) {
+	if k.IsCtrl('c') {
+		a.quit = true
+		return
+	}
+
+	// Messages last until the next key
+	if a.prompt == nil {
+		a.status = ""
+	}
+
+	switch {
+	case a.prompt != nil:
+		a.handlePromptKey(k)
+	case a.stores != nil:
+		a.handleStoreKey(k)
+	case a.filtering:
+		a.handleFilterKey(k)
+	case a.focusHistory:
+		a.handleHistoryKey(k)
+	default:
+		a.handleTreeKey(k)
+	}
+}
+
+func (a *uiApp) handleTreeKey(k tui.Key) {
+	if a.list.HandleKey(k, len(a.rows), a.bodyHeight()) {
+		return
+	}
+
+	row := a.selectedRow()
+	switch {
+	case k.IsRune('j'):
+		a.list.Move(1, len(a.rows))
+	case k.IsRune('k'):
+		a.list.Move(-1, len(a.rows))
+	case k.Type == tui.KeyRight || k.IsRune('l'):
+		if row != nil && row.dir && !a.expanded[row.path] {
+			a.expanded[row.path] = true
+			a.buildRows()
+		}
+	case k.Type == tui.KeyLeft || k.IsRune('h'):
+		a.collapse(row)
+	case k.Type == tui.KeyEnter || k.IsRune(' ') || k.Type == tui.KeyTab:
+		if row != nil && row.dir {
+			a.expanded[row.path] = !a.expanded[row.path]
+			a.buildRows()
+		} else if len(a.history) > 0 {
+			a.focusHistory = true
+		}
+	case k.Type == tui.KeyEsc:
+		if a.filter.Value() != "" {
+			a.filter.Set("")
+			a.buildRows()
+		}
+	default:
+		a.handleAction(k)
+	}
+}
+
+// collapse folds the selected directory, or moves to the parent directory
+func (a *uiApp) collapse(row *uiRow) {
+	if row == nil {
+		return
+	}
+	if row.dir && a.expanded[row.path] {
+		a.expanded[row.path] = false
+		a.buildRows()
+		return
+	}
+
+	parent, _, ok := cutLast(row.path, "/")
+	if !ok || a.filter.Value() != "" {
+		return
+	}
+	for i, r := range a.rows {
+		if r.dir && r.path == parent {
+			a.list.Cursor = i
+			return
+		}
+	}
+}
+
+func (a *uiApp) handleHistoryKey(k tui.Key) {
+	if a.versions.HandleKey(k, len(a.history), a.bodyHeight()) {
+		return
+	}
+
+	switch {
+	case k.IsRune('j'):
+		a.versions.Move(1, len(a.history))
+	case k.IsRune('k'):
+		a.versions.Move(-1, len(a.history))
+	case k.Type == tui.KeyTab || k.Type == tui.KeyEsc || k.Type == tui.KeyLeft || k.IsRune('h'):
+		a.focusHistory = false
+	case k.IsRune('b'):
+		a.rollback()
+	default:
+		a.handleAction(k)
+	}
+}
+
+func (a *uiApp) handleFilterKey(k tui.Key) {
+	switch {
+	case k.Type == tui.KeyEsc:
+		a.filter.Set("")
+		a.filtering = false
+		a.buildRows()
+	case k.Type == tui.KeyEnter || k.Type == tui.KeyTab:
+		a.filtering = false
+	case a.list.HandleKey(k, len(a.rows), a.bodyHeight()):
+	case a.filter.HandleKey(k):
+		a.buildRows()
+	}
+}
+
+// handleAction handles the keys shared by the tree and the history pane
+func (a *uiApp) handleAction(k tui.Key) {
+	switch {
+	case k.IsRune('q'):
+		a.quit = true
+	case k.IsRune('/'):
+		a.filtering = true
+		a.focusHistory = false
+	case k.IsRune('r'):
+		a.reveal()
+	case k.IsRune('c'):
+		a.copy()
+	case k.IsRune('p'):
+		a.put()
+	case k.IsRune('n'):
+		a.newEntry()
+	case k.IsRune('d'):
+		a.delete()
+	case k.IsRune('s'):
+		a.selectStore()
+	case k.IsRune('R') || k.IsCtrl('l'):
+		a.reload()
+		a.setStatus("Reloaded store '%s'", a.storeName)
+	}
+}
+
+func (a *uiApp) handlePromptKey(k tui.Key) {
+	p := a.prompt
+
+	if p.confirm {
+		a.prompt = nil
+		if k.IsRune('y') || k.IsRune('Y') {
+			p.submit("y")
+		} else {
+			a.setStatus("Cancelled")
+		}
+		return
+	}
+
+	switch {
+	case k.Type == tui.KeyEsc:
+		a.prompt = nil
+		a.setStatus("Cancelled")
+	case k.Type == tui.KeyEnter:
+		a.prompt = nil
+		p.submit(p.input.Value())
+	default:
+		p.input.HandleKey(k)
+	}
+}
+
+func (a *uiApp) ask(label string, masked bool, submit func(string)) {
+	a.prompt = &uiPrompt{label: label, submit: submit}
+	a.prompt.input.Mask = masked
+}
+
+func (a *uiApp) confirm(question string, yes func()) {
+	a.prompt = &uiPrompt{label: question + " [y/N]", confirm: true, submit: func(string) { yes() }}
+}
+
+func (a *uiApp) reveal() {
+	key, version := a.selectedKey(), a.selectedVersion()
+	if key == "" {
+		return
+	}
+	if a.revealKey == key && a.revealVersion == version {
+		a.revealKey, a.revealed = "", ""
+		return
+	}
+
+	secret, err := a.store.Get(key, version)
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	a.revealKey, a.revealVersion, a.revealed = key, version, secret
+}
+
+func (a *uiApp) copy() {
+	key, version := a.selectedKey(), a.selectedVersion()
+	if key == "" {
+		return
+	}
+
+	secret, err := a.store.Get(key, version)
+	if err != nil {
+		a.setError(err)
+		return
+	}
+
+	// Entries with fields copy their password, others the whole secret
+	value := secret
+	if password, fields := store.ParseFields(secret); len(fields) > 0 {
+		value = password
+	}
+
+	if err := copyToClipboard(a.cfg, value, a.cfg.ClipboardTimeout); err != nil {
+		a.setError(err)
+		return
+	}
+
+	a.clipUntil = time.Time{}
+	if a.cfg.ClipboardTimeout > 0 {
+		a.clipUntil = time.Now().Add(a.cfg.ClipboardTimeout)
+	}
+	a.setStatus("Copied '%s' version %d", key, version)
+}
+
+// askPassword prompts for a password twice and stores it under key
+func (a *uiApp) askPassword(key, message string) {
+	a.ask("Password for "+key+":", true, func(password string) {
+		if password == "" {
+			a.setStatus("Cancelled, the password is empty")
+			return
+		}
+		a.ask("Confirm password:", true, func(confirm string) {
+			if confirm != password {
+				a.setError(errors.New("passwords do not match"))
+				return
+			}
+			if err := a.store.Put(key, password, message); err != nil {
+				a.setError(err)
+				return
+			}
+			a.setStatus("Password stored for '%s'", key)
+			a.reload()
+			a.selectKey(key)
+		})
+	})
+}
+
+func (a *uiApp) put() {
+	if key := a.selectedKey(); key != "" {
+		a.askPassword(key, "")
+	}
+}
+
+func (a *uiApp) newEntry() {
+	prefix := ""
+	if row := a.selectedRow(); row != nil && a.filter.Value() == "" {
+		if row.dir {
+			prefix = row.path + "/"
+		} else if parent, _, ok := cutLast(row.path, "/"); ok {
+			prefix = parent + "/"
+		}
+	}
+
+	a.ask("New key:", false, func(key string) {
+		key = strings.Trim(strings.TrimSpace(key), "/")
+		switch {
+		case key == "":
+			a.setStatus("Cancelled, the key is empty")
+		case a.store.Exists(key):
+			a.setError(fmt.Errorf("'%s' already exists, use p to put a new version", key))
+		default:
+			a.askPassword(key, "")
+		}
+	})
+	a.prompt.input.Set(prefix)
+}
+
+func (a *uiApp) rollback() {
+	key, version := a.selectedKey(), a.selectedVersion()
+	if key == "" || version == 0 || version == a.history[0].Version {
+		a.setStatus("Select an older version to roll back to")
+		return
+	}
+
+	a.confirm(fmt.Sprintf("Roll back '%s' to version %d?", key, version), func() {
+		password, err := a.store.Get(key, version)
+		if err != nil {
+			a.setError(err)
+			return
+		}
+		if err := a.store.Put(key, password, fmt.Sprintf("Rollback to version %d", version)); err != nil {
+			a.setError(err)
+			return
+		}
+		a.historyKey = ""
+		a.setStatus("Rolled back '%s' to version %d", key, version)
+	})
+}
+
+func (a *uiApp) delete() {
+	key := a.selectedKey()
+	if key == "" {
+		return
+	}
+
+	a.confirm(fmt.Sprintf("Delete '%s' and all its versions?", key), func() {
+		if err := a.store.Delete(key); err != nil {
+			a.setError(err)
+			return
+		}
+		a.reload()
+		a.setStatus("Deleted '%s'", key)
+	})
+}
+
+// selectKey moves the cursor to key, expanding its directories
+func (a *uiApp) selectKey(key string) {
+	if a.filter.Value() == "" {
+		parts := strings.Split(key, "/")
+		for i := 1; i < len(parts); i++ {
+			a.expanded[strings.Join(parts[:i], "/")] = true
+		}
+		a.buildRows()
+	}
+
+	for i, row := range a.rows {
+		if !row.dir && row.path == key {
+			a.list.Cursor = i
+		}
+	}
+}
+
+func (a *uiApp) selectStore() {
+	a.stores = nil
+	for name := range a.cfg.Stores {
+		a.stores = append(a.stores, name)
+	}
+	sort.Strings(a.stores)
+
+	a.storeList = tui.List{}
+	for i, name := range a.stores {
+		if name == a.storeName {
+			a.storeList.Cursor = i
+		}
+	}
+}
+
+func (a *uiApp) handleStoreKey(k tui.Key) {
+	if a.storeList.HandleKey(k, len(a.stores), a.bodyHeight()) {
+		return
+	}
+
+	switch {
+	case k.IsRune('j'):
+		a.storeList.Move(1, len(a.stores))
+	case k.IsRune('k'):
+		a.storeList.Move(-1, len(a.stores))
+	case k.Type == tui.KeyEsc || k.IsRune('q'):
+		a.stores = nil
+	case k.Type == tui.KeyEnter:
+		name := a.stores[a.storeList.Cursor]
+		a.stores = nil
+		a.switchStore(name)
+	}
+}
+
+func (a *uiApp) switchStore(name string) {
+	storeConfig := a.cfg.Stores[name]
+	s, err := store.New(storeConfig.Path, keyring.New(a.cfg.IdentitySources(name)))
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	s.SetAuthor(os.Getenv("USER"))
+
+	// Give identity passphrase prompts the terminal
+	a.term.Suspend()
+	identityErr := s.LoadIdentities()
+	if err := a.term.Resume(); err != nil {
+		a.setError(err)
+		a.quit = true
+		return
+	}
+
+	a.storeName, a.store = name, s
+	a.expanded = make(map[string]bool)
+	a.filter.Set("")
+	a.list = tui.List{}
+	a.revealKey, a.revealed = "", ""
+	a.reload()
+
+	if identityErr != nil {
+		a.setError(identityErr)
+	} else {
+		a.setStatus("Switched to store '%s'", name)
+	}
+}
+
+func (a *uiApp) draw() {
+	width, height := a.term.Size()
+	body := a.bodyHeight()
+
+	lines := []string{a.titleLine(width), a.filterLine(width)}
+
+	leftWidth := width
+	if width >= 60 {
+		leftWidth = max(24, width*2/5)
+	}
+	rightWidth := width - leftWidth - 1
+
+	var left []string
+	if a.stores != nil {
+		left = a.storeLines(leftWidth, body)
+	} else {
+		left = a.treeLines(leftWidth, body)
+	}
+	right := a.detailLines(rightWidth, body)
+
+	for i := 0; i < body; i++ {
+		line := strings.Repeat(" ", leftWidth)
+		if i < len(left) {
+			line = left[i]
+		}
+		if rightWidth > 0 {
+			line += tui.Dim("│")
+			if i < len(right) {
+				line += right[i]
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, a.statusLine(width))
+	a.term.Draw(lines[:min(len(lines), height)])
+}
+
+func (a *uiApp) titleLine(width int) string {
+	title := fmt.Sprintf(" pf · store '%s' · %d entries", a.storeName, len(a.keys))
+	right := ""
+	if !a.clipUntil.IsZero() {
+		remaining := time.Until(a.clipUntil).Round(time.Second)
+		right = fmt.Sprintf("clipboard clears in %s ", max(remaining, 0))
+	}
+	return tui.Reverse(tui.Fit(title, width-tui.Width(right)) + right)
+}
+
+func (a *uiApp) filterLine(width int) string {
+	if !a.filtering && a.filter.Value() == "" {
+		return tui.Dim(tui.Fit(" / to filter", width))
+	}
+
+	line := " / " + a.filter.View(width-4, a.filtering)
+	if a.filter.Value() != "" {
+		line += tui.Dim(fmt.Sprintf("  %d/%d", len(a.rows), len(a.keys)))
+	}
+	return line
+}
+
+func (a *uiApp) treeLines(width, height int) []string {
+	if len(a.rows) == 0 {
+		if len(a.keys) == 0 {
+			return []string{tui.Dim(tui.Fit(" No passwords in this store", width))}
+		}
+		return []string{tui.Dim(tui.Fit(" No matches", width))}
+	}
+
+	var lines []string
+	start, end := a.list.Window(len(a.rows), height)
+	for i := start; i < end; i++ {
+		row := a.rows[i]
+
+		marker := "  "
+		if row.dir {
+			marker = "▸ "
+			if a.expanded[row.path] {
+				marker = "▾ "
+			}
+		}
+		indent := " " + strings.Repeat("  ", row.depth) + marker
+		name := row.name
+		if row.dir {
+			name += "/"
+		}
+
+		// Shift match positions past the indent
+		positions := make([]int, len(row.positions))
+		for n, pos := range row.positions {
+			positions[n] = pos + tui.Width(indent)
+		}
+
+		line := tui.Highlight(tui.Fit(indent+name, width), positions, func(s string) string {
+			return tui.Bold(tui.Accent(s))
+		})
+		switch {
+		case i == a.list.Cursor && !a.focusHistory:
+			line = tui.Reverse(line)
+		case i == a.list.Cursor:
+			line = tui.Bold(line)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (a *uiApp) storeLines(width, height int) []string {
+	lines := []string{tui.Bold(tui.Fit(" Switch store (Enter, Esc to cancel)", width))}
+
+	start, end := a.storeList.Window(len(a.stores), height-1)
+	for i := start; i < end; i++ {
+		name := a.stores[i]
+		label := "   " + name
+		if name == a.storeName {
+			label = " • " + name
+		}
+		line := tui.Fit(label, width)
+		if i == a.storeList.Cursor {
+			line = tui.Reverse(line)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (a *uiApp) detailLines(width, height int) []string {
+	if width <= 0 {
+		return nil
+	}
+
+	fit := func(s string) string { return tui.Fit(" "+s, width) }
+
+	row := a.selectedRow()
+	switch {
+	case row == nil:
+		return nil
+	case row.dir:
+		count := 0
+		for _, key := range a.keys {
+			if strings.HasPrefix(key, row.path+"/") {
+				count++
+			}
+		}
+		noun := "entries"
+		if count == 1 {
+			noun = "entry"
+		}
+		return []string{tui.Bold(fit(row.path + "/")), fit(fmt.Sprintf("%d %s", count, noun))}
+	}
+
+	lines := []string{tui.Bold(fit(row.path))}
+	if a.entry != nil {
+		if expires, ok := a.entry.ExpiresAt(); ok {
+			line := fit("Expires " + a.entry.Expires)
+			if time.Now().After(expires) {
+				line = tui.Warn(line)
+			}
+			lines = append(lines, line)
+		}
+		if a.entry.RotateEvery != "" {
+			lines = append(lines, fit("Rotate every "+a.entry.RotateEvery))
+		}
+	}
+	lines = append(lines, "", tui.Dim(fit("Versions")))
+
+	// The revealed password takes the bottom of the pane
+	var secret []string
+	version := a.selectedVersion()
+	if a.revealKey == row.path && a.revealVersion == version {
+		secret = append(secret, "", tui.Dim(fit(fmt.Sprintf("Version %d", version))))
+		for _, line := range strings.Split(strings.TrimRight(a.revealed, "\n"), "\n") {
+			secret = append(secret, tui.Accent(fit(line)))
+		}
+	}
+
+	room := height - len(lines) - len(secret)
+	if room < 1 {
+		secret = nil
+		room = height - len(lines)
+	}
+
+	start, end := a.versions.Window(len(a.history), room)
+	for i := start; i < end; i++ {
+		v := a.history[i]
+		text := fmt.Sprintf("v%-3d %s", v.Version, time.Unix(v.Timestamp, 0).Format("2006-01-02 15:04"))
+		if v.Author != "" {
+			text += "  " + v.Author
+		}
+		if v.Message != "" {
+			text += "  " + v.Message
+		}
+		if i == 0 {
+			text += "  (current)"
+		}
+
+		line := fit(text)
+		if a.focusHistory && i == a.versions.Cursor {
+			line = tui.Reverse(line)
+		}
+		lines = append(lines, line)
+	}
+
+	return append(lines, secret...)
+}
+
+func (a *uiApp) statusLine(width int) string {
+	switch {
+	case a.prompt != nil:
+		label := " " + a.prompt.label + " "
+		if a.prompt.confirm {
+			return tui.Bold(label)
+		}
+		return tui.Bold(label) + a.prompt.input.View(width-tui.Width(label)-1, true)
+	case a.status != "" && a.statusErr:
+		return tui.Warn(tui.Fit(" "+a.status, width))
+	case a.status != "":
+		return tui.Fit(" "+a.status, width)
+	case a.focusHistory:
+		return tui.Dim(tui.Fit(" ↑↓ version  r reveal  c copy  b roll back  tab tree  q quit", width))
+	default:
+		return tui.Dim(tui.Fit(" ↑↓ move  ←→ fold  / filter  tab history  r reveal  c copy  p put  n new  d delete  s store  q quit", width))
+	}
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (string, string, bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,162 @@
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	system "github.com/atotto/clipboard"
+)
+
+// Backend names
+const (
+	Auto   = "auto"
+	System = "system"
+	OSC52  = "osc52"
+	WlCopy = "wl-copy"
+	Xclip  = "xclip"
+	Xsel   = "xsel"
+	File   = "file"
+)
+
+// Backends lists the backend names New accepts
+var Backends = []string{Auto, System, OSC52, WlCopy, Xclip, Xsel, File}
+
+// ErrReadUnsupported is returned by backends that can only write, such as
+// OSC 52 terminals and FIFOs
+var ErrReadUnsupported = errors.New("clipboard cannot be read")
+
+// Backend reads and writes a clipboard
+type Backend interface {
+	// Name and Path identify the backend, New(Name(), Path()) returns the
+	// same backend. Path is the terminal or file written to, if any.
+	Name() string
+	Path() string
+
+	Read() (string, error)
+	Write(text string) error
+}
+
+// New returns the named backend. path is the file of the file backend and
+// the terminal of the OSC 52 backend (default: the current terminal). An
+// empty name or "auto" detects the backend from the environment.
+func New(name, path string) (Backend, error) {
+	switch name {
+	case "", Auto:
+		return New(Detect(), path)
+	case System:
+		return systemBackend{}, nil
+	case OSC52:
+		if path == "" {
+			path = terminalPath()
+		}
+		return &osc52Backend{path: path}, nil
+	case WlCopy:
+		return &commandBackend{
+			name:  WlCopy,
+			write: []string{"wl-copy"},
+			clear: []string{"wl-copy", "--clear"},
+			read:  []string{"wl-paste", "--no-newline"},
+		}, nil
+	case Xclip:
+		return &commandBackend{
+			name:  Xclip,
+			write: []string{"xclip", "-in", "-selection", "clipboard"},
+			read:  []string{"xclip", "-out", "-selection", "clipboard"},
+		}, nil
+	case Xsel:
+		return &commandBackend{
+			name:  Xsel,
+			write: []string{"xsel", "--clipboard", "--input"},
+			clear: []string{"xsel", "--clipboard", "--delete"},
+			read:  []string{"xsel", "--clipboard", "--output"},
+		}, nil
+	case File:
+		if path == "" {
+			return nil, fmt.Errorf("the file clipboard backend needs a path")
+		}
+		return &fileBackend{path: path}, nil
+	default:
+		return nil, fmt.Errorf("unknown clipboard backend '%s' (expected one of %s)", name, strings.Join(Backends, ", "))
+	}
+}
+
+// Detect returns the backend to use in this environment: Wayland or X11
+// tools when a display is available, the system clipboard on macOS and
+// Windows, and OSC 52 in remote or headless terminal sessions
+func Detect() string {
+	switch {
+	case os.Getenv("WAYLAND_DISPLAY") != "" && installed("wl-copy"):
+		return WlCopy
+	case os.Getenv("DISPLAY") != "" && installed("xclip"):
+		return Xclip
+	case os.Getenv("DISPLAY") != "" && installed("xsel"):
+		return Xsel
+	case runtime.GOOS == "darwin" || runtime.GOOS == "windows":
+		return System
+	case os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" || hasTerminal():
+		return OSC52
+	default:
+		return System
+	}
+}
+
+func installed(command string) bool {
+	_, err := exec.LookPath(command)
+	return err == nil
+}
+
+// systemBackend uses the platform clipboard through github.com/atotto/clipboard
+type systemBackend struct{}
+
+func (systemBackend) Name() string { return System }
+func (systemBackend) Path() string { return "" }
+
+func (systemBackend) Read() (string, error) {
+	return system.ReadAll()
+}
+
+func (systemBackend) Write(text string) error {
+	return system.WriteAll(text)
+}
+
+// commandBackend runs clipboard tools such as xclip
+type commandBackend struct {
+	name  string
+	write []string
+
+	// clear empties the clipboard, write with no input is used when nil
+	clear []string
+	read  []string
+}
+
+func (b *commandBackend) Name() string { return b.name }
+func (b *commandBackend) Path() string { return "" }
+
+func (b *commandBackend) Read() (string, error) {
+	out, err := exec.Command(b.read[0], b.read[1:]...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", b.read[0], err)
+	}
+	return string(out), nil
+}
+
+func (b *commandBackend) Write(text string) error {
+	args := b.write
+	if text == "" && b.clear != nil {
+		args = b.clear
+	}
+
+	// xclip and wl-copy fork a child that serves the selection until it is
+	// taken over. It inherits captured output pipes, so output is left
+	// uncaptured and only the tool itself is waited for.
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", args[0], err)
+	}
+	return nil
+}
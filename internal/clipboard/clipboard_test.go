@@ -0,0 +1,64 @@
+package clipboard
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestCommandBackendForkingTool checks that Write returns once the tool
+// exits, while the child it forks to serve the selection keeps running
+// with the tool's stdout and stderr, as xclip and wl-copy do
+func TestCommandBackendForkingTool(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake clipboard tool is a shell script")
+	}
+
+	dir := t.TempDir()
+	selection := filepath.Join(dir, "selection")
+	tool := filepath.Join(dir, "fake-xclip")
+	script := "#!/bin/sh\ncat > '" + selection + "'\nsleep 30 &\necho serving\n"
+	if err := os.WriteFile(tool, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &commandBackend{name: Xclip, write: []string{tool}}
+
+	done := make(chan error, 1)
+	go func() { done <- b.Write("s3cret") }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Write waited for the forked child")
+	}
+
+	data, err := os.ReadFile(selection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "s3cret" {
+		t.Errorf("tool read %q, want %q", data, "s3cret")
+	}
+}
+
+func TestCommandBackendFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake clipboard tool is a shell script")
+	}
+
+	tool := filepath.Join(t.TempDir(), "fake-xclip")
+	if err := os.WriteFile(tool, []byte("#!/bin/sh\nexit 1\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &commandBackend{name: Xclip, write: []string{tool}}
+	if err := b.Write("s3cret"); err == nil {
+		t.Error("Write succeeded although the tool failed")
+	}
+}
@@ -0,0 +1,62 @@
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// fileBackend keeps the clipboard in a file, or writes it to a FIFO, for
+// tests and scripts
+type fileBackend struct {
+	path string
+}
+
+func (b *fileBackend) Name() string { return File }
+func (b *fileBackend) Path() string { return b.path }
+
+func (b *fileBackend) Read() (string, error) {
+	if b.isFIFO() {
+		return "", ErrReadUnsupported
+	}
+
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read clipboard file: %w", err)
+	}
+	return string(data), nil
+}
+
+// Write replaces the file, or blocks until the FIFO is read
+func (b *fileBackend) Write(text string) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if b.isFIFO() {
+		flags = os.O_WRONLY
+	}
+
+	file, err := os.OpenFile(b.path, flags, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open clipboard file: %w", err)
+	}
+	if flags&os.O_CREATE != 0 {
+		// The file may predate the clipboard
+		if err := file.Chmod(0600); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to secure clipboard file: %w", err)
+		}
+	}
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write clipboard file: %w", err)
+	}
+	return file.Close()
+}
+
+func (b *fileBackend) isFIFO() bool {
+	info, err := os.Stat(b.path)
+	return err == nil && info.Mode()&fs.ModeNamedPipe != 0
+}
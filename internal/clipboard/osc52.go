@@ -0,0 +1,83 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// osc52Backend sets the clipboard of the terminal emulator with an OSC 52
+// escape sequence, which works through SSH. Terminals don't reliably allow
+// reading the clipboard back, so it can only be written.
+type osc52Backend struct {
+	path string
+}
+
+func (b *osc52Backend) Name() string { return OSC52 }
+func (b *osc52Backend) Path() string { return b.path }
+
+func (b *osc52Backend) Read() (string, error) {
+	return "", ErrReadUnsupported
+}
+
+func (b *osc52Backend) Write(text string) error {
+	tty, err := os.OpenFile(b.path, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open terminal: %w", err)
+	}
+	defer tty.Close()
+
+	// An empty payload clears the clipboard
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+
+	// tmux and screen only forward sequences wrapped in DCS passthrough,
+	// tmux with its escape characters doubled
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = "\x1bP" + seq + "\x1b\\"
+	}
+
+	if _, err := tty.WriteString(seq); err != nil {
+		return fmt.Errorf("failed to write to terminal: %w", err)
+	}
+	return nil
+}
+
+// terminalPath returns the device of the current terminal, so the sequence
+// can still be written by a process without a controlling terminal
+func terminalPath() string {
+	if runtime.GOOS == "windows" {
+		return "CONOUT$"
+	}
+
+	for _, fd := range []int{2, 1, 0} {
+		if !term.IsTerminal(fd) {
+			continue
+		}
+		if path, err := os.Readlink(fmt.Sprintf("/proc/self/fd/%d", fd)); err == nil {
+			return path
+		}
+	}
+	return "/dev/tty"
+}
+
+// hasTerminal reports whether a terminal is available to write sequences to
+func hasTerminal() bool {
+	for _, fd := range []int{2, 1, 0} {
+		if term.IsTerminal(fd) {
+			return true
+		}
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return false
+	}
+	tty.Close()
+	return true
+}
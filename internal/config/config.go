@@ -16,24 +16,98 @@ type Config struct {
 	AgeKeyPath      string                  `yaml:"age_key_path"`
 	ClipboardTimeout time.Duration          `yaml:"clipboard_timeout"`
 	AuditLog        bool                    `yaml:"audit_log"`
+	BreachDataset   string                  `yaml:"breach_dataset,omitempty"`
+	Identities      []IdentitySource        `yaml:"identities,omitempty"`
+	APIClients      []APIClient             `yaml:"api_clients,omitempty"`
+	GitCredential   GitCredential           `yaml:"git_credential,omitempty"`
+	DockerCredential DockerCredential       `yaml:"docker_credential,omitempty"`
+	Clipboard       Clipboard               `yaml:"clipboard,omitempty"`
+}
+
+// DefaultClipboardTimeout is how long copied passwords stay in the clipboard
+const DefaultClipboardTimeout = 45 * time.Second
+
+// DefaultGitCredentialKey is the default key template of pf git-credential
+const DefaultGitCredentialKey = "git/{host}/{username}"
+
+// GitCredential configures the Git credential helper
+type GitCredential struct {
+	// Key maps a credential to a key, with {protocol}, {host}, {path} and
+	// {username} placeholders. Empty means DefaultGitCredentialKey.
+	Key string `yaml:"key,omitempty"`
+
+	// Store is the store to use, the default store when empty
+	Store string `yaml:"store,omitempty"`
+}
+
+// DefaultDockerCredentialPrefix is the default key prefix of pf docker-credential
+const DefaultDockerCredentialPrefix = "docker"
+
+// DockerCredential configures the Docker credential helper
+type DockerCredential struct {
+	// Prefix is the key prefix registry credentials are stored under.
+	// Empty means DefaultDockerCredentialPrefix.
+	Prefix string `yaml:"prefix,omitempty"`
+
+	// Store is the store to use, the default store when empty
+	Store string `yaml:"store,omitempty"`
+}
+
+// Clipboard configures how passwords are copied
+type Clipboard struct {
+	// Backend is auto (the default), system, osc52, wl-copy, xclip, xsel or file
+	Backend string `yaml:"backend,omitempty"`
+
+	// Path is the file of the file backend, or the terminal of the osc52
+	// backend (default: the current terminal)
+	Path string `yaml:"path,omitempty"`
+}
+
+// APIClient is a client allowed to use the pf serve API
+type APIClient struct {
+	Name string `yaml:"name"`
+
+	// TokenHash is the hex SHA-256 of the client's token
+	TokenHash string `yaml:"token_hash"`
+
+	// Scopes are the key prefixes the client may access, all keys when empty
+	Scopes []string `yaml:"scopes,omitempty"`
 }
 
 // StoreConfig represents a password store configuration
 type StoreConfig struct {
 	Path       string   `yaml:"path"`
 	Recipients []string `yaml:"recipients"`
+
+	// Identities are tried before the global identities for this store
+	Identities []IdentitySource `yaml:"identities,omitempty"`
+}
+
+// IdentitySource describes where age identities are loaded from. Exactly
+// one of File, Env and Command is set.
+type IdentitySource struct {
+	// File is an identity file, optionally passphrase-protected
+	File string `yaml:"file,omitempty"`
+
+	// Env is an environment variable holding the identity
+	Env string `yaml:"env,omitempty"`
+
+	// Command is run with sh -c and prints the identity on stdout
+	Command string `yaml:"command,omitempty"`
 }
 
 // Load loads the configuration from disk
 func Load() (*Config, error) {
+	return LoadFile((&Config{}).GetConfigPath())
+}
+
+// LoadFile loads the configuration from configPath
+func LoadFile(configPath string) (*Config, error) {
 	cfg := &Config{
-		ClipboardTimeout: 45 * time.Second,
+		ClipboardTimeout: DefaultClipboardTimeout,
 		Stores:          make(map[string]StoreConfig),
 	}
 
-	// Get config path
-	configPath := cfg.GetConfigPath()
-
 	// Check if config file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		// Return default config
@@ -51,7 +125,11 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
-	// Set defaults if not specified
+	// Set defaults if not specified, the clipboard is always cleared unless
+	// a command is told otherwise
+	if cfg.ClipboardTimeout <= 0 {
+		cfg.ClipboardTimeout = DefaultClipboardTimeout
+	}
 	if cfg.AgeKeyPath == "" {
 		home, err := os.UserHomeDir()
 		if err != nil {
@@ -63,6 +141,24 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// IdentitySources returns the identity sources for a store: the store's own
+// sources followed by the global ones. Without global sources, the age key
+// file is used.
+func (c *Config) IdentitySources(storeName string) []IdentitySource {
+	var sources []IdentitySource
+	if storeConfig, ok := c.Stores[storeName]; ok {
+		sources = append(sources, storeConfig.Identities...)
+	}
+
+	if len(c.Identities) > 0 {
+		return append(sources, c.Identities...)
+	}
+	if c.AgeKeyPath != "" {
+		sources = append(sources, IdentitySource{File: c.AgeKeyPath})
+	}
+	return sources
+}
+
 // GetConfigPath returns the configuration file path
 func (c *Config) GetConfigPath() string {
 	// Check viper for custom config path
@@ -0,0 +1,34 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Start re-executes the current binary with args as a detached background
+// process and returns its process ID. The process gets its own session so it
+// outlives the caller.
+func Start(args []string, stdin io.Reader) (int, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	cmd := exec.Command(executable, args...)
+	cmd.Stdin = stdin
+	cmd.SysProcAttr = sysProcAttr()
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start background process: %w", err)
+	}
+
+	// Don't wait for the child, it is reparented once we exit
+	pid := cmd.Process.Pid
+	if err := cmd.Process.Release(); err != nil {
+		return 0, err
+	}
+
+	return pid, nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package daemon
+
+import "syscall"
+
+func sysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{}
+}
@@ -0,0 +1,132 @@
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Scoring of matched characters
+const (
+	scoreMatch       = 16
+	bonusBoundary    = 10
+	bonusConsecutive = 6
+	penaltyGap       = 1
+)
+
+// Result is an item matching a pattern
+type Result struct {
+	// Index is the position of the item in the filtered slice
+	Index int
+
+	Score int
+
+	// Positions are the rune indices of the matched characters
+	Positions []int
+}
+
+// Filter returns the items matching pattern, best first. Items with equal
+// scores keep their order, and an empty pattern matches every item.
+func Filter(pattern string, items []string) []Result {
+	var results []Result
+	for i, item := range items {
+		if score, positions, ok := Match(pattern, item); ok {
+			results = append(results, Result{Index: i, Score: score, Positions: positions})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// Match reports whether every space-separated term of pattern occurs in s
+// as a subsequence, and scores the match. Matching ignores case unless the
+// term contains an uppercase letter.
+func Match(pattern, s string) (int, []int, bool) {
+	text := []rune(s)
+	lower := []rune(strings.ToLower(s))
+
+	total := 0
+	var positions []int
+	for _, term := range strings.Fields(pattern) {
+		haystack := lower
+		if strings.ToLower(term) != term {
+			haystack = text
+		}
+
+		score, matched, ok := matchTerm([]rune(term), haystack, text)
+		if !ok {
+			return 0, nil, false
+		}
+		total += score
+		positions = append(positions, matched...)
+	}
+
+	sort.Ints(positions)
+	return total, positions, true
+}
+
+// matchTerm finds the shortest match of term ending at its first possible
+// end, as fzf's v1 algorithm does, and scores it
+func matchTerm(term, haystack, text []rune) (int, []int, bool) {
+	if len(term) == 0 {
+		return 0, nil, true
+	}
+
+	// Forward scan for the end of the first match
+	end := -1
+	t := 0
+	for i, r := range haystack {
+		if r == term[t] {
+			t++
+			if t == len(term) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	// Backward scan from there for the latest start
+	positions := make([]int, len(term))
+	t = len(term) - 1
+	for i := end; i >= 0 && t >= 0; i-- {
+		if haystack[i] == term[t] {
+			positions[t] = i
+			t--
+		}
+	}
+
+	score := 0
+	for n, pos := range positions {
+		score += scoreMatch
+		if pos == 0 || isBoundary(text[pos-1], text[pos]) {
+			score += bonusBoundary
+		}
+		if n > 0 {
+			if gap := pos - positions[n-1] - 1; gap == 0 {
+				score += bonusConsecutive
+			} else {
+				score -= gap * penaltyGap
+			}
+		}
+	}
+
+	// Shorter items rank higher among equal matches
+	score -= len(text) / 8
+
+	return score, positions, true
+}
+
+// isBoundary reports whether cur starts a word after prev
+func isBoundary(prev, cur rune) bool {
+	switch prev {
+	case '/', '-', '_', '.', ' ', ':', '@':
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
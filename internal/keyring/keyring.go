@@ -0,0 +1,144 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	pfage "pf/internal/age"
+	"pf/internal/config"
+)
+
+// Keyring loads age identities from a list of identity sources
+type Keyring struct {
+	sources []config.IdentitySource
+}
+
+// SourceError reports an identity source that failed to load
+type SourceError struct {
+	Source config.IdentitySource
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("identity source %s: %v", Describe(e.Source), e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// New creates a keyring over the given sources, loaded in order
+func New(sources []config.IdentitySource) *Keyring {
+	return &Keyring{sources: sources}
+}
+
+// Sources returns the keyring's identity sources
+func (k *Keyring) Sources() []config.IdentitySource {
+	return k.sources
+}
+
+// Load loads the identities of every source. Sources that fail are skipped
+// and reported in the returned error, so the identities that did load can
+// still be used.
+func (k *Keyring) Load() ([]*pfage.Identity, error) {
+	return k.LoadContext(context.Background())
+}
+
+// LoadContext is like Load, ctx bounds the identity commands
+func (k *Keyring) LoadContext(ctx context.Context) ([]*pfage.Identity, error) {
+	if len(k.sources) == 0 {
+		return nil, fmt.Errorf("no identities configured")
+	}
+
+	var identities []*pfage.Identity
+	var errs []error
+	for _, source := range k.sources {
+		loaded, err := LoadSourceContext(ctx, source)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		identities = append(identities, loaded...)
+	}
+
+	return identities, errors.Join(errs...)
+}
+
+// LoadSource loads the identities of a single source. Errors are returned
+// as *SourceError.
+func LoadSource(source config.IdentitySource) ([]*pfage.Identity, error) {
+	return LoadSourceContext(context.Background(), source)
+}
+
+// LoadSourceContext is like LoadSource, ctx bounds identity commands
+func LoadSourceContext(ctx context.Context, source config.IdentitySource) ([]*pfage.Identity, error) {
+	var identities []*pfage.Identity
+	var err error
+
+	switch {
+	case source.File != "":
+		identities, err = pfage.LoadIdentityFile(expandHome(source.File))
+	case source.Env != "":
+		value := os.Getenv(source.Env)
+		if value == "" {
+			err = fmt.Errorf("variable is not set")
+		} else {
+			identities, err = pfage.LoadIdentities([]byte(value), "$"+source.Env)
+		}
+	case source.Command != "":
+		identities, err = runCommand(ctx, source.Command)
+	default:
+		err = fmt.Errorf("one of file, env or command must be set")
+	}
+
+	if err != nil {
+		return nil, &SourceError{Source: source, Err: err}
+	}
+	return identities, nil
+}
+
+// Describe returns a short description of a source for messages
+func Describe(source config.IdentitySource) string {
+	switch {
+	case source.File != "":
+		return "file " + source.File
+	case source.Env != "":
+		return "env $" + source.Env
+	case source.Command != "":
+		return fmt.Sprintf("command '%s'", source.Command)
+	default:
+		return "(empty)"
+	}
+}
+
+// runCommand loads identities printed by a command. The command shares the
+// terminal so it can prompt, for example to unlock a secrets manager.
+func runCommand(ctx context.Context, command string) ([]*pfage.Identity, error) {
+	c := exec.CommandContext(ctx, "sh", "-c", command)
+	c.Stdin = os.Stdin
+	c.Stderr = os.Stderr
+
+	output, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("command failed: %w", err)
+	}
+
+	return pfage.LoadIdentities(output, "command output")
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
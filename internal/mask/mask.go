@@ -0,0 +1,91 @@
+package mask
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Replacement is written in place of masked secrets
+const Replacement = "*****"
+
+// Writer replaces secrets in a stream before writing it to an underlying
+// writer. Output that could be the start of a secret is held back until the
+// next write shows whether it is, so secrets split across writes are still
+// masked. Close flushes what is held back.
+type Writer struct {
+	mu      sync.Mutex
+	w       io.Writer
+	secrets [][]byte
+	pending []byte
+}
+
+// NewWriter creates a Writer masking secrets. Empty secrets are ignored.
+func NewWriter(w io.Writer, secrets []string) *Writer {
+	var list [][]byte
+	for _, secret := range secrets {
+		if secret != "" {
+			list = append(list, []byte(secret))
+		}
+	}
+
+	// Longest first, so a secret containing another is masked whole
+	sort.Slice(list, func(i, j int) bool {
+		return len(list[i]) > len(list[j])
+	})
+
+	return &Writer{w: w, secrets: list}
+}
+
+// Write masks p and writes it, holding back a possible partial secret
+func (m *Writer) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pending = append(m.pending, p...)
+	masked := m.mask(m.pending)
+
+	hold := m.partialSuffix(masked)
+	if _, err := m.w.Write(masked[:len(masked)-hold]); err != nil {
+		return 0, err
+	}
+	m.pending = append(m.pending[:0], masked[len(masked)-hold:]...)
+
+	return len(p), nil
+}
+
+// Close flushes held back output
+func (m *Writer) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.pending) == 0 {
+		return nil
+	}
+	_, err := m.w.Write(m.pending)
+	m.pending = nil
+	return err
+}
+
+func (m *Writer) mask(data []byte) []byte {
+	for _, secret := range m.secrets {
+		data = bytes.ReplaceAll(data, secret, []byte(Replacement))
+	}
+	return data
+}
+
+// partialSuffix returns the length of the longest suffix of data that is
+// the beginning of a secret
+func (m *Writer) partialSuffix(data []byte) int {
+	longest := 0
+	for _, secret := range m.secrets {
+		for n := len(secret) - 1; n > longest; n-- {
+			if n <= len(data) && bytes.HasSuffix(data, secret[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
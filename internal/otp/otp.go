@@ -0,0 +1,144 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoKey is returned when a secret contains no otpauth:// URI
+var ErrNoKey = errors.New("no otpauth:// URI in the secret")
+
+// Key is a TOTP key (RFC 6238) from an otpauth:// URI
+type Key struct {
+	// URI is the otpauth:// URI the key was parsed from
+	URI       string
+	Issuer    string
+	Account   string
+	Algorithm string
+	Digits    int
+	Period    time.Duration
+
+	secret []byte
+}
+
+// Find returns the key of the first otpauth:// URI in a secret, on a line
+// of its own or as a field such as "otpauth: otpauth://totp/..."
+func Find(secret string) (*Key, error) {
+	for _, line := range strings.Split(secret, "\n") {
+		if i := strings.Index(line, "otpauth://"); i >= 0 {
+			return Parse(strings.TrimSpace(line[i:]))
+		}
+	}
+	return nil, ErrNoKey
+}
+
+// Parse parses an otpauth://totp/ URI. Algorithm defaults to SHA1, digits
+// to 6 and period to 30 seconds.
+func Parse(uri string) (*Key, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid otpauth URI: %w", err)
+	}
+	if u.Scheme != "otpauth" {
+		return nil, fmt.Errorf("invalid otpauth URI: scheme %q", u.Scheme)
+	}
+	if u.Host != "totp" {
+		return nil, fmt.Errorf("unsupported OTP type %q, only totp is supported", u.Host)
+	}
+
+	q := u.Query()
+	key := &Key{URI: uri, Algorithm: "SHA1", Digits: 6, Period: 30 * time.Second}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		key.Issuer, key.Account = issuer, strings.TrimSpace(account)
+	} else {
+		key.Account = label
+	}
+	if issuer := q.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+
+	// Base32 secrets are often shown in lower case, grouped and unpadded
+	secret := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(q.Get("secret")))
+	if secret == "" {
+		return nil, fmt.Errorf("invalid otpauth URI: no secret")
+	}
+	key.secret, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid otpauth URI: secret is not base32")
+	}
+
+	if algorithm := q.Get("algorithm"); algorithm != "" {
+		key.Algorithm = strings.ToUpper(algorithm)
+		if newHash(key.Algorithm) == nil {
+			return nil, fmt.Errorf("unsupported OTP algorithm %q", algorithm)
+		}
+	}
+	if digits := q.Get("digits"); digits != "" {
+		key.Digits, err = strconv.Atoi(digits)
+		if err != nil || key.Digits < 6 || key.Digits > 10 {
+			return nil, fmt.Errorf("invalid otpauth URI: digits %q", digits)
+		}
+	}
+	if period := q.Get("period"); period != "" {
+		seconds, err := strconv.Atoi(period)
+		if err != nil || seconds < 1 {
+			return nil, fmt.Errorf("invalid otpauth URI: period %q", period)
+		}
+		key.Period = time.Duration(seconds) * time.Second
+	}
+
+	return key, nil
+}
+
+// Code returns the code valid at t
+func (k *Key) Code(t time.Time) string {
+	counter := uint64(t.Unix()) / uint64(k.Period/time.Second)
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(newHash(k.Algorithm), k.secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := uint64(binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff)
+
+	mod := uint64(1)
+	for i := 0; i < k.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", k.Digits, value%mod)
+}
+
+// Remaining returns how long the code valid at t stays valid
+func (k *Key) Remaining(t time.Time) time.Duration {
+	period := int64(k.Period / time.Second)
+	return time.Duration(period-t.Unix()%period) * time.Second
+}
+
+func newHash(algorithm string) func() hash.Hash {
+	switch algorithm {
+	case "SHA1":
+		return sha1.New
+	case "SHA256":
+		return sha256.New
+	case "SHA512":
+		return sha512.New
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,53 @@
+package qrterm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+// quietZone is the number of light modules around the code, as the QR
+// specification requires
+const quietZone = 4
+
+// Colors of the code: black modules on a white background, whatever the
+// terminal's own colors
+const (
+	colors = "\x1b[30;107m"
+	reset  = "\x1b[0m"
+)
+
+// Render writes text as a QR code drawn with Unicode half blocks, two
+// modules per character cell. Dark modules are drawn with the foreground,
+// in black on white, so the code is not inverted on any terminal.
+func Render(w io.Writer, text string) error {
+	code, err := qr.Encode(text, qr.L)
+	if err != nil {
+		return fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	var b strings.Builder
+	for y := -quietZone; y < code.Size+quietZone; y += 2 {
+		b.WriteString(colors)
+		for x := -quietZone; x < code.Size+quietZone; x++ {
+			// Black reports false outside of the code, in the quiet zone
+			top, bottom := code.Black(x, y), code.Black(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(reset + "\n")
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
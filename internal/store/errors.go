@@ -0,0 +1,81 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors, match them with errors.Is
+var (
+	ErrNotFound          = errors.New("password not found")
+	ErrNoIdentity        = errors.New("no identity available")
+	ErrDecrypt           = errors.New("decryption failed")
+	ErrVersionOutOfRange = errors.New("version out of range")
+	ErrCorruptEntry      = errors.New("corrupt entry")
+)
+
+// NotFoundError is returned for keys without an entry
+type NotFoundError struct {
+	Key string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("password '%s' not found", e.Key)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// VersionError is returned when a requested version does not exist
+type VersionError struct {
+	Key     string
+	Version int
+	Latest  int
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("version %d of '%s' does not exist (latest is %d)", e.Version, e.Key, e.Latest)
+}
+
+func (e *VersionError) Is(target error) bool {
+	return target == ErrVersionOutOfRange
+}
+
+// DecryptError is returned when a version cannot be decrypted with the
+// available identities
+type DecryptError struct {
+	Key     string
+	Version int
+	Err     error
+}
+
+func (e *DecryptError) Error() string {
+	return fmt.Sprintf("failed to decrypt version %d of '%s': %v", e.Version, e.Key, e.Err)
+}
+
+func (e *DecryptError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DecryptError) Is(target error) bool {
+	return target == ErrDecrypt
+}
+
+// CorruptEntryError is returned for entry files that cannot be parsed
+type CorruptEntryError struct {
+	Key string
+	Err error
+}
+
+func (e *CorruptEntryError) Error() string {
+	return fmt.Sprintf("entry '%s' is corrupt: %v", e.Key, e.Err)
+}
+
+func (e *CorruptEntryError) Unwrap() error {
+	return e.Err
+}
+
+func (e *CorruptEntryError) Is(target error) bool {
+	return target == ErrCorruptEntry
+}
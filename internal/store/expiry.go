@@ -0,0 +1,137 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"pf/internal/audit"
+)
+
+// DateFormat is the layout of entry expiry dates
+const DateFormat = "2006-01-02"
+
+// Deadline kinds
+const (
+	DeadlineExpires = "expires"
+	DeadlineRotate  = "rotate"
+)
+
+// ParseInterval parses a duration, also accepting whole days ("30d") and weeks ("2w")
+func ParseInterval(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid interval: %s", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid interval: %s", s)
+	}
+	return d, nil
+}
+
+// ExpiresAt returns the expiry date of the entry
+func (e *Entry) ExpiresAt() (time.Time, bool) {
+	if e.Expires == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(DateFormat, e.Expires, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// RotationDue returns when the entry should be rotated, counted from the
+// timestamp of its latest version
+func (e *Entry) RotationDue() (time.Time, bool) {
+	if e.RotateEvery == "" || len(e.Versions) == 0 {
+		return time.Time{}, false
+	}
+	interval, err := ParseInterval(e.RotateEvery)
+	if err != nil {
+		return time.Time{}, false
+	}
+	latest := e.Versions[len(e.Versions)-1]
+	return time.Unix(latest.Timestamp, 0).Add(interval), true
+}
+
+// Deadline returns the earliest of the expiry date and the rotation due date,
+// along with its kind
+func (e *Entry) Deadline() (time.Time, string, bool) {
+	expires, hasExpiry := e.ExpiresAt()
+	rotate, hasRotation := e.RotationDue()
+
+	switch {
+	case hasExpiry && (!hasRotation || !rotate.Before(expires)):
+		return expires, DeadlineExpires, true
+	case hasRotation:
+		return rotate, DeadlineRotate, true
+	default:
+		return time.Time{}, "", false
+	}
+}
+
+// Info retrieves an entry's metadata and version history without passwords
+func (s *Store) Info(key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.loadEntry(key)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entry.Versions {
+		entry.Versions[i].Password = ""
+	}
+
+	return entry, nil
+}
+
+// ValidateExpiry checks an expiry date and rotation interval, empty values
+// are valid
+func ValidateExpiry(expires, rotateEvery string) error {
+	if expires != "" {
+		if _, err := time.Parse(DateFormat, expires); err != nil {
+			return fmt.Errorf("invalid expiry date %s (expected YYYY-MM-DD)", expires)
+		}
+	}
+	if rotateEvery != "" {
+		if _, err := ParseInterval(rotateEvery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetExpiry sets the expiry date and rotation interval of an entry.
+// Empty values clear the corresponding setting.
+func (s *Store) SetExpiry(key, expires, rotateEvery string) error {
+	if err := ValidateExpiry(expires, rotateEvery); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Log audit event
+	s.Audit(audit.EventModify, key, "Updated expiry")
+
+	entry, err := s.loadEntry(key)
+	if err != nil {
+		return err
+	}
+
+	entry.Expires = expires
+	entry.RotateEvery = rotateEvery
+
+	return s.saveEntry(entry)
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"sort"
+	"strings"
+)
+
+// FieldPassword names the first line of a secret
+const FieldPassword = "password"
+
+// ParseFields splits a secret into its password and fields. The first line
+// is the password, later lines of the form "name: value" are fields:
+//
+//	s3cret
+//	username: deploy
+//	url: https://db.example.com
+//
+// Field names are case-insensitive, other lines are ignored.
+func ParseFields(secret string) (string, map[string]string) {
+	lines := strings.Split(secret, "\n")
+	password := strings.TrimRight(lines[0], "\r")
+
+	fields := make(map[string]string)
+	for _, line := range lines[1:] {
+		name, value, ok := strings.Cut(strings.TrimRight(line, "\r"), ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			continue
+		}
+		fields[strings.ToLower(name)] = strings.TrimSpace(value)
+	}
+
+	return password, fields
+}
+
+// Field returns a field of a secret, FieldPassword for its first line
+func Field(secret, name string) (string, bool) {
+	password, fields := ParseFields(secret)
+	if strings.EqualFold(name, FieldPassword) {
+		return password, true
+	}
+
+	value, ok := fields[strings.ToLower(name)]
+	return value, ok
+}
+
+// SetFields returns secret with its password replaced and fields set.
+// Existing lines of those fields are replaced, the first in place, and new
+// fields are appended in sorted order. Other lines are kept.
+func SetFields(secret, password string, fields map[string]string) string {
+	values := make(map[string]string, len(fields))
+	for name, value := range fields {
+		values[strings.ToLower(name)] = value
+	}
+
+	lines := []string{password}
+	set := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimRight(secret, "\n"), "\n")[1:] {
+		name, _, ok := strings.Cut(line, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if value, found := values[name]; ok && found {
+			if !set[name] {
+				lines = append(lines, name+": "+value)
+				set[name] = true
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var names []string
+	for name := range values {
+		if !set[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, name+": "+values[name])
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
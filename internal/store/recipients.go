@@ -0,0 +1,167 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+
+	pfage "pf/internal/age"
+	"pf/internal/audit"
+)
+
+// Recipients returns the recipients new versions are encrypted for
+func (s *Store) Recipients() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.recipients
+}
+
+// SetIdentities replaces the identities used for decryption
+func (s *Store) SetIdentities(identities []age.Identity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cache.identities = identities
+	s.cache.err = nil
+	s.cache.explicit = true
+}
+
+// ReplaceRecipients rewrites the store's .recipients file, replacing every
+// line matching one of old with newRecipient. Comments and other recipients
+// are kept. It reports whether the file changed.
+func (s *Store) ReplaceRecipients(old []string, newRecipient string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := filepath.Join(s.path, ".recipients")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read recipients: %w", err)
+	}
+
+	hasNew := false
+	for _, r := range s.recipients {
+		if pfage.SameRecipient(r, newRecipient) {
+			hasNew = true
+		}
+	}
+
+	changed := false
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") && matchesAny(trimmed, old) {
+			changed = true
+			// Keep a single copy of the new recipient
+			if hasNew {
+				continue
+			}
+			line, hasNew = newRecipient, true
+		}
+		lines = append(lines, line)
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		return false, fmt.Errorf("failed to write recipients: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return false, fmt.Errorf("failed to write recipients: %w", err)
+	}
+
+	s.recipients, err = loadRecipients(path)
+	return true, err
+}
+
+// Reencrypt re-encrypts every version of an entry for the store's current
+// recipients, except versions that skip can already decrypt. It returns the
+// number of versions re-encrypted.
+func (s *Store) Reencrypt(key string, skip []age.Identity) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.loadEntry(key)
+	if err != nil {
+		return 0, err
+	}
+
+	identities, err := s.loadIdentities()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load identities: %w", err)
+	}
+
+	count := 0
+	for i, v := range entry.Versions {
+		if _, err := pfage.Decrypt(v.Password, skip); err == nil {
+			continue
+		}
+
+		password, err := pfage.Decrypt(v.Password, identities)
+		if err != nil {
+			// Not encrypted for us, leave it alone
+			continue
+		}
+
+		encrypted, err := pfage.Encrypt(password, s.recipients)
+		if err != nil {
+			return count, fmt.Errorf("failed to encrypt version %d: %w", v.Version, err)
+		}
+		entry.Versions[i].Password = encrypted
+		count++
+	}
+
+	if count == 0 {
+		return 0, nil
+	}
+
+	// Log audit event
+	s.Audit(audit.EventModify, key, fmt.Sprintf("Re-encrypted %d versions", count))
+
+	return count, s.saveEntry(entry)
+}
+
+func matchesAny(recipient string, candidates []string) bool {
+	for _, candidate := range candidates {
+		if pfage.SameRecipient(recipient, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanDecrypt reports whether identity decrypts the latest version of any
+// entry in the store, entries may be encrypted to different recipients.
+// Empty stores report false.
+func (s *Store) CanDecrypt(identity age.Identity) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.list()
+	if err != nil {
+		return false, err
+	}
+
+	for _, key := range keys {
+		entry, err := s.loadEntry(key)
+		if err != nil || len(entry.Versions) == 0 {
+			continue
+		}
+		latest := entry.Versions[len(entry.Versions)-1]
+		if _, err := pfage.Decrypt(latest.Password, []age.Identity{identity}); err == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
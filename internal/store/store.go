@@ -1,32 +1,50 @@
 package store
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
 	"filippo.io/age"
 
 	pfage "pf/internal/age"
+	"pf/internal/agent"
 	"pf/internal/audit"
+	"pf/internal/keyring"
 )
 
-// Store represents a password store
+// Store represents a password store. It is safe for concurrent use.
 type Store struct {
 	path       string
 	recipients []string
-	identities []age.Identity
+	keyring    *keyring.Keyring
+	cache      *identityCache
+	author     string
 	auditor    *audit.Logger
+	mu         *sync.Mutex
+}
+
+// identityCache holds the identities loaded for decryption, shared by the
+// views returned by WithAuthor. Identities set explicitly are used alone,
+// without asking the agent.
+type identityCache struct {
+	identities []age.Identity
+	err        error
+	explicit   bool
 }
 
 // Entry represents a password entry with versioning
 type Entry struct {
-	Key      string    `yaml:"key"`
-	Versions []Version `yaml:"versions"`
+	Key         string    `yaml:"key"`
+	Expires     string    `yaml:"expires,omitempty"`
+	RotateEvery string    `yaml:"rotate_every,omitempty"`
+	Versions    []Version `yaml:"versions"`
 }
 
 // Version represents a single version of a password
@@ -38,8 +56,9 @@ type Version struct {
 	Message   string `yaml:"message,omitempty"`
 }
 
-// New creates a new Store instance
-func New(path, ageKeyPath string) (*Store, error) {
+// New creates a new Store instance. Identities for decryption are loaded
+// from kr on first use, kr may be nil for stores that are only written to.
+func New(path string, kr *keyring.Keyring) (*Store, error) {
 	// Load recipients from .recipients file
 	recipientsFile := filepath.Join(path, ".recipients")
 	recipients, err := loadRecipients(recipientsFile)
@@ -47,31 +66,35 @@ func New(path, ageKeyPath string) (*Store, error) {
 		return nil, fmt.Errorf("failed to load recipients: %w", err)
 	}
 
-	// Load identities
-	var identities []age.Identity
-	if ageKeyPath != "" {
-		identities, err = pfage.LoadIdentityFile(ageKeyPath)
-		if err != nil {
-			// Non-fatal: might be a shared store
-			identities = []age.Identity{}
-		}
-	}
-
 	// Initialize auditor
 	auditor := audit.New(filepath.Join(path, ".audit.log"))
 
+	// Identities are loaded on first decryption, shared stores may not have any
 	return &Store{
 		path:       path,
 		recipients: recipients,
-		identities: identities,
+		keyring:    kr,
+		cache:      &identityCache{},
 		auditor:    auditor,
+		mu:         &sync.Mutex{},
 	}, nil
 }
 
+// WithAuthor returns a view of the store that records author on new
+// versions and audit events. The view shares the store's lock and identities.
+func (s *Store) WithAuthor(author string) *Store {
+	view := *s
+	view.author = author
+	return &view
+}
+
 // Get retrieves a password by key
 func (s *Store) Get(key string, version int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// Log audit event
-	s.auditor.Log(audit.EventAccess, key, "")
+	s.Audit(audit.EventAccess, key, "")
 
 	// Load entry
 	entry, err := s.loadEntry(key)
@@ -79,27 +102,65 @@ func (s *Store) Get(key string, version int) (string, error) {
 		return "", err
 	}
 
-	// Get requested version
-	if version <= 0 || version > len(entry.Versions) {
-		// Get latest version
+	// Get requested version, 0 is the latest
+	if version == 0 {
 		version = len(entry.Versions)
 	}
+	if version < 1 || version > len(entry.Versions) {
+		return "", &VersionError{Key: key, Version: version, Latest: len(entry.Versions)}
+	}
 
 	v := entry.Versions[version-1]
 
+	// Load identities
+	identities, err := s.loadIdentities()
+	if err != nil {
+		return "", err
+	}
+
 	// Decrypt password
-	password, err := pfage.Decrypt(v.Password, s.identities)
+	password, err := pfage.Decrypt(v.Password, identities)
 	if err != nil {
-		return "", fmt.Errorf("failed to decrypt password: %w", err)
+		// Sources that failed to load may hold the matching identity
+		return "", &DecryptError{Key: key, Version: version, Err: errors.Join(err, s.cache.err)}
 	}
 
 	return password, nil
 }
 
+// SetAuthor sets the author recorded on new versions and audit events
+func (s *Store) SetAuthor(author string) {
+	s.author = author
+}
+
+// Audit writes an audit event attributed to the store's author
+func (s *Store) Audit(event, key, details string) {
+	s.auditor.LogAs(s.author, event, key, details)
+}
+
+// LoadIdentities loads the identities used for decryption now rather than
+// on first use
+func (s *Store) LoadIdentities() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.loadIdentities()
+	return err
+}
+
+// Exists reports whether an entry exists for key
+func (s *Store) Exists(key string) bool {
+	_, err := os.Stat(s.getEntryPath(key))
+	return err == nil
+}
+
 // Put stores a new password or updates an existing one
 func (s *Store) Put(key, password, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// Log audit event
-	s.auditor.Log(audit.EventModify, key, message)
+	s.Audit(audit.EventModify, key, message)
 
 	// Encrypt password
 	encrypted, err := pfage.Encrypt(password, s.recipients)
@@ -109,12 +170,14 @@ func (s *Store) Put(key, password, message string) error {
 
 	// Load or create entry
 	entry, err := s.loadEntry(key)
-	if err != nil {
+	if errors.Is(err, ErrNotFound) {
 		// Create new entry
 		entry = &Entry{
 			Key:      key,
 			Versions: []Version{},
 		}
+	} else if err != nil {
+		return err
 	}
 
 	// Add new version
@@ -122,7 +185,7 @@ func (s *Store) Put(key, password, message string) error {
 		Version:   len(entry.Versions) + 1,
 		Password:  encrypted,
 		Timestamp: time.Now().Unix(),
-		Author:    os.Getenv("USER"),
+		Author:    s.author,
 		Message:   message,
 	}
 	entry.Versions = append(entry.Versions, newVersion)
@@ -133,14 +196,17 @@ func (s *Store) Put(key, password, message string) error {
 
 // Delete removes a password entry
 func (s *Store) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// Log audit event
-	s.auditor.Log(audit.EventDelete, key, "")
+	s.Audit(audit.EventDelete, key, "")
 
 	// Remove entry file
 	entryPath := s.getEntryPath(key)
 	if err := os.Remove(entryPath); err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("password '%s' not found", key)
+			return &NotFoundError{Key: key}
 		}
 		return fmt.Errorf("failed to delete password: %w", err)
 	}
@@ -150,6 +216,9 @@ func (s *Store) Delete(key string) error {
 
 // GetHistory retrieves the version history of a password
 func (s *Store) GetHistory(key string, limit int) ([]Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// Load entry
 	entry, err := s.loadEntry(key)
 	if err != nil {
@@ -180,6 +249,15 @@ func (s *Store) GetHistory(key string, limit int) ([]Version, error) {
 
 // List returns all password keys in the store
 func (s *Store) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.list()
+}
+
+// Helper functions
+
+func (s *Store) list() ([]string, error) {
 	var keys []string
 	
 	// Walk through the directory tree
@@ -223,7 +301,78 @@ func (s *Store) List() ([]string, error) {
 	return keys, nil
 }
 
-// Helper functions
+// loadIdentities returns the identities used for decryption, preferring a
+// running agent over reading the key file. The agent is asked again on every
+// use, as it may be locked or stopped while the store is open.
+func (s *Store) loadIdentities() ([]age.Identity, error) {
+	if s.cache.explicit {
+		return s.cache.identities, nil
+	}
+
+	if client, err := agent.Dial(); err == nil {
+		if status, err := client.Status(); err == nil && status.Identities > 0 {
+			return []age.Identity{agentIdentity{client.Identity()}, keyringIdentity{s}}, nil
+		}
+	}
+
+	return s.loadKeyring()
+}
+
+// loadKeyring loads and caches the identities of the keyring sources
+func (s *Store) loadKeyring() ([]age.Identity, error) {
+	if s.cache.identities != nil {
+		return s.cache.identities, nil
+	}
+
+	if s.keyring == nil {
+		return nil, fmt.Errorf("%w: no identities configured", ErrNoIdentity)
+	}
+
+	identities, err := s.keyring.Load()
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("%w: %w", ErrNoIdentity, err)
+	}
+
+	s.cache.err = err
+	s.cache.identities = pfage.AgeIdentities(identities)
+	return s.cache.identities, nil
+}
+
+// agentIdentity unwraps through the agent, reporting a locked or stopped
+// agent as a non-matching identity so the keyring is tried next
+type agentIdentity struct {
+	age.Identity
+}
+
+func (i agentIdentity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	fileKey, err := i.Identity.Unwrap(stanzas)
+	if err != nil {
+		return nil, age.ErrIncorrectIdentity
+	}
+	return fileKey, nil
+}
+
+// keyringIdentity loads the keyring sources when the agent cannot unwrap
+type keyringIdentity struct {
+	store *Store
+}
+
+func (i keyringIdentity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	identities, err := i.store.loadKeyring()
+	if err != nil {
+		i.store.cache.err = err
+		return nil, age.ErrIncorrectIdentity
+	}
+
+	for _, identity := range identities {
+		fileKey, err := identity.Unwrap(stanzas)
+		if errors.Is(err, age.ErrIncorrectIdentity) {
+			continue
+		}
+		return fileKey, err
+	}
+	return nil, age.ErrIncorrectIdentity
+}
 
 func (s *Store) getEntryPath(key string) string {
 	// Support hierarchical structure
@@ -238,14 +387,17 @@ func (s *Store) loadEntry(key string) (*Entry, error) {
 	data, err := os.ReadFile(entryPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("password '%s' not found", key)
+			return nil, &NotFoundError{Key: key}
 		}
 		return nil, fmt.Errorf("failed to read entry: %w", err)
 	}
 
 	var entry Entry
 	if err := yaml.Unmarshal(data, &entry); err != nil {
-		return nil, fmt.Errorf("failed to parse entry: %w", err)
+		return nil, &CorruptEntryError{Key: key, Err: err}
+	}
+	if len(entry.Versions) == 0 {
+		return nil, &CorruptEntryError{Key: key, Err: errors.New("no versions")}
 	}
 
 	return &entry, nil
@@ -265,7 +417,14 @@ func (s *Store) saveEntry(entry *Entry) error {
 		return fmt.Errorf("failed to marshal entry: %w", err)
 	}
 
-	if err := os.WriteFile(entryPath, data, 0600); err != nil {
+	// Write to a temporary file first so an interrupted save never leaves
+	// a truncated entry behind
+	tmpPath := entryPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to save entry: %w", err)
+	}
+	if err := os.Rename(tmpPath, entryPath); err != nil {
+		os.Remove(tmpPath)
 		return fmt.Errorf("failed to save entry: %w", err)
 	}
 
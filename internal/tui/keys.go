@@ -0,0 +1,133 @@
+package tui
+
+import "unicode/utf8"
+
+// KeyType identifies a key
+type KeyType int
+
+// Key types. KeyRune is a printable character, KeyCtrl a letter with Ctrl.
+const (
+	KeyRune KeyType = iota
+	KeyCtrl
+	KeyEnter
+	KeyEsc
+	KeyBackspace
+	KeyDelete
+	KeyTab
+	KeyBacktab
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyPgUp
+	KeyPgDn
+)
+
+// Key is a key press
+type Key struct {
+	Type KeyType
+
+	// Rune is the character of KeyRune and the lowercase letter of KeyCtrl
+	Rune rune
+}
+
+// IsRune reports whether k is the printable character r
+func (k Key) IsRune(r rune) bool {
+	return k.Type == KeyRune && k.Rune == r
+}
+
+// IsCtrl reports whether k is Ctrl and the letter r
+func (k Key) IsCtrl(r rune) bool {
+	return k.Type == KeyCtrl && k.Rune == r
+}
+
+// escapeKeys maps the final byte of CSI and SS3 sequences without parameters
+var escapeKeys = map[byte]KeyType{
+	'A': KeyUp,
+	'B': KeyDown,
+	'C': KeyRight,
+	'D': KeyLeft,
+	'H': KeyHome,
+	'F': KeyEnd,
+	'Z': KeyBacktab,
+}
+
+// tildeKeys maps the parameter of "ESC [ n ~" sequences
+var tildeKeys = map[string]KeyType{
+	"1": KeyHome,
+	"7": KeyHome,
+	"4": KeyEnd,
+	"8": KeyEnd,
+	"3": KeyDelete,
+	"5": KeyPgUp,
+	"6": KeyPgDn,
+}
+
+// ParseKeys decodes the keys in terminal input. An escape at the end of the
+// input is the Esc key, unknown sequences are skipped.
+func ParseKeys(data []byte) []Key {
+	var keys []Key
+	for len(data) > 0 {
+		key, n, ok := parseKey(data)
+		data = data[n:]
+		if ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// parseKey decodes the key at the start of data and returns its length
+func parseKey(data []byte) (Key, int, bool) {
+	switch b := data[0]; {
+	case b == 0x1b:
+		return parseEscape(data)
+	case b == '\r' || b == '\n':
+		return Key{Type: KeyEnter}, 1, true
+	case b == '\t':
+		return Key{Type: KeyTab}, 1, true
+	case b == 0x7f || b == 0x08:
+		return Key{Type: KeyBackspace}, 1, true
+	case b >= 1 && b <= 26:
+		return Key{Type: KeyCtrl, Rune: rune('a' + b - 1)}, 1, true
+	case b < 0x20:
+		return Key{}, 1, false
+	}
+
+	r, n := utf8.DecodeRune(data)
+	if r == utf8.RuneError {
+		return Key{}, n, false
+	}
+	return Key{Type: KeyRune, Rune: r}, n, true
+}
+
+func parseEscape(data []byte) (Key, int, bool) {
+	if len(data) == 1 || (data[1] != '[' && data[1] != 'O') {
+		return Key{Type: KeyEsc}, 1, true
+	}
+
+	// Parameters run up to a final byte in 0x40-0x7e
+	for i := 2; i < len(data); i++ {
+		final := data[i]
+		if final < 0x40 || final > 0x7e {
+			continue
+		}
+
+		params := string(data[2:i])
+		if final == '~' {
+			if t, ok := tildeKeys[params]; ok {
+				return Key{Type: t}, i + 1, true
+			}
+			return Key{}, i + 1, false
+		}
+		if t, ok := escapeKeys[final]; ok && (params == "" || params == "1") {
+			return Key{Type: t}, i + 1, true
+		}
+		return Key{}, i + 1, false
+	}
+
+	// Incomplete sequence
+	return Key{}, len(data), false
+}
@@ -0,0 +1,242 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Terminal is the controlling terminal in raw mode. Full-screen terminals
+// draw on the alternate screen, inline ones in the lines below the cursor.
+type Terminal struct {
+	in, out    *os.File
+	inFd       int
+	outFd      int
+	state      *term.State
+	fullscreen bool
+
+	keys   chan Key
+	resize chan os.Signal
+
+	// paused and resume hand the input over to Suspend and back
+	paused chan struct{}
+	resume chan struct{}
+
+	// closing stops readKeys, which closes done when it returns
+	closing chan struct{}
+	done    chan struct{}
+
+	mu sync.Mutex
+}
+
+// ErrNoTerminal is returned when there is no terminal to draw on
+var ErrNoTerminal = errors.New("no terminal available")
+
+// Open puts the controlling terminal in raw mode and starts reading keys
+func Open(fullscreen bool) (*Terminal, error) {
+	in, out, err := openTTY()
+	if err != nil {
+		return nil, ErrNoTerminal
+	}
+	if !term.IsTerminal(fileFd(in)) {
+		return nil, ErrNoTerminal
+	}
+
+	t := &Terminal{
+		in:         in,
+		out:        out,
+		inFd:       fileFd(in),
+		outFd:      fileFd(out),
+		fullscreen: fullscreen,
+		keys:       make(chan Key, 64),
+		resize:     make(chan os.Signal, 1),
+		paused:     make(chan struct{}),
+		resume:     make(chan struct{}),
+		closing:    make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	if err := t.enter(); err != nil {
+		return nil, err
+	}
+
+	notifyResize(t.resize)
+	go t.readKeys()
+
+	return t, nil
+}
+
+// enter switches to raw mode and prepares the screen
+func (t *Terminal) enter() error {
+	state, err := term.MakeRaw(t.inFd)
+	if err != nil {
+		return fmt.Errorf("failed to set raw mode: %w", err)
+	}
+	t.state = state
+
+	if t.fullscreen {
+		fmt.Fprint(t.out, "\x1b[?1049h\x1b[H")
+	}
+	fmt.Fprint(t.out, "\x1b[?25l")
+	return nil
+}
+
+// leave restores the terminal and clears what was drawn
+func (t *Terminal) leave() {
+	if t.fullscreen {
+		fmt.Fprint(t.out, "\x1b[?1049l")
+	} else {
+		fmt.Fprint(t.out, "\r\x1b[J")
+	}
+	fmt.Fprint(t.out, "\x1b[?25h")
+	term.Restore(t.inFd, t.state)
+}
+
+// Close restores the terminal
+func (t *Terminal) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	signal.Stop(t.resize)
+
+	// Stop reading first, so a terminal opened next gets all the input
+	close(t.closing)
+	if t.in.SetReadDeadline(time.Now()) == nil {
+		<-t.done
+	}
+
+	t.leave()
+	t.in.Close()
+	if t.out != t.in {
+		t.out.Close()
+	}
+	return nil
+}
+
+// Keys returns the channel keys are delivered on. It is closed when the
+// terminal can no longer be read.
+func (t *Terminal) Keys() <-chan Key {
+	return t.keys
+}
+
+// Resized returns a channel signalled when the terminal size changes
+func (t *Terminal) Resized() <-chan os.Signal {
+	return t.resize
+}
+
+// Size returns the terminal width and height
+func (t *Terminal) Size() (int, int) {
+	width, height, err := term.GetSize(t.outFd)
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// Suspend restores the terminal so a prompt can read from it, and stops
+// reading keys until Resume
+func (t *Terminal) Suspend() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Interrupt the pending read, where the terminal supports deadlines
+	if t.in.SetReadDeadline(time.Now()) == nil {
+		<-t.paused
+	}
+	t.leave()
+}
+
+// Resume switches back to raw mode after Suspend
+func (t *Terminal) Resume() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.enter(); err != nil {
+		return err
+	}
+	if t.in.SetReadDeadline(time.Time{}) == nil {
+		t.resume <- struct{}{}
+	}
+	return nil
+}
+
+// Draw replaces the drawn lines. Full-screen terminals draw from the top,
+// inline ones from the cursor line, leaving the cursor there.
+func (t *Terminal) Draw(lines []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var b strings.Builder
+	if t.fullscreen {
+		b.WriteString("\x1b[H")
+	} else {
+		b.WriteString("\r")
+	}
+
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line)
+		b.WriteString("\x1b[0m\x1b[K")
+	}
+	b.WriteString("\x1b[J")
+
+	if !t.fullscreen && len(lines) > 1 {
+		fmt.Fprintf(&b, "\x1b[%dA\r", len(lines)-1)
+	}
+
+	t.out.WriteString(b.String())
+}
+
+// readKeys delivers keys until the terminal is closed
+func (t *Terminal) readKeys() {
+	defer close(t.done)
+	defer close(t.keys)
+
+	buf := make([]byte, 256)
+	for {
+		n, err := t.in.Read(buf)
+		for _, key := range ParseKeys(buf[:n]) {
+			select {
+			case t.keys <- key:
+			case <-t.closing:
+				return
+			}
+		}
+
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			select {
+			case t.paused <- struct{}{}:
+				<-t.resume
+				continue
+			case <-t.closing:
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// fileFd returns the descriptor of f. Unlike f.Fd, it leaves f in
+// non-blocking mode, so read deadlines can interrupt reads.
+func fileFd(f *os.File) int {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return int(f.Fd())
+	}
+
+	fd := -1
+	conn.Control(func(raw uintptr) {
+		fd = int(raw)
+	})
+	return fd
+}
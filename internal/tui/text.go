@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Bold styles text bold
+func Bold(s string) string { return "\x1b[1m" + s + "\x1b[22m" }
+
+// Dim styles text faint
+func Dim(s string) string { return "\x1b[2m" + s + "\x1b[22m" }
+
+// Reverse swaps the foreground and background of text
+func Reverse(s string) string { return "\x1b[7m" + s + "\x1b[27m" }
+
+// Accent styles text in the accent color
+func Accent(s string) string { return "\x1b[36m" + s + "\x1b[39m" }
+
+// Warn styles text in the warning color
+func Warn(s string) string { return "\x1b[31m" + s + "\x1b[39m" }
+
+// Width returns the width of text, one cell per rune, not counting the
+// escape sequences of styled text
+func Width(s string) int {
+	width := 0
+	for len(s) > 0 {
+		// CSI sequences end with a byte in 0x40-0x7e
+		if rest, ok := strings.CutPrefix(s, "\x1b["); ok {
+			end := strings.IndexFunc(rest, func(r rune) bool { return r >= 0x40 && r <= 0x7e })
+			if end < 0 {
+				break
+			}
+			s = rest[end+1:]
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		width++
+	}
+	return width
+}
+
+// Fit truncates plain text to width cells, ending with an ellipsis when
+// cut, and pads it with spaces to width
+func Fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	runes := []rune(s)
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
+
+// Highlight styles the runes of plain text at positions
+func Highlight(s string, positions []int, style func(string) string) string {
+	if len(positions) == 0 {
+		return s
+	}
+
+	marked := make(map[int]bool, len(positions))
+	for _, pos := range positions {
+		marked[pos] = true
+	}
+
+	var b strings.Builder
+	for i, r := range []rune(s) {
+		if marked[i] {
+			b.WriteString(style(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,22 @@
+//go:build !unix
+
+package tui
+
+import "os"
+
+// openTTY opens the console
+func openTTY() (*os.File, *os.File, error) {
+	in, err := os.OpenFile("CONIN$", os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err := os.OpenFile("CONOUT$", os.O_RDWR, 0)
+	if err != nil {
+		in.Close()
+		return nil, nil, err
+	}
+	return in, out, nil
+}
+
+// notifyResize does nothing, the size is read on every draw
+func notifyResize(c chan os.Signal) {}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package tui
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// openTTY opens the controlling terminal, so stdin and stdout stay free
+func openTTY() (*os.File, *os.File, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tty, tty, nil
+}
+
+func notifyResize(c chan os.Signal) {
+	signal.Notify(c, syscall.SIGWINCH)
+}
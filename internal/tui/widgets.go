@@ -0,0 +1,172 @@
+package tui
+
+import "strings"
+
+// List tracks the cursor and scroll offset of a list of items
+type List struct {
+	Cursor int
+	Offset int
+}
+
+// Move moves the cursor by delta, within n items
+func (l *List) Move(delta, n int) {
+	l.Cursor += delta
+	l.Clamp(n)
+}
+
+// Clamp keeps the cursor within n items
+func (l *List) Clamp(n int) {
+	if l.Cursor >= n {
+		l.Cursor = n - 1
+	}
+	if l.Cursor < 0 {
+		l.Cursor = 0
+	}
+}
+
+// HandleKey moves the cursor for navigation keys, a page being page items,
+// and reports whether the key was handled
+func (l *List) HandleKey(k Key, n, page int) bool {
+	switch {
+	case k.Type == KeyUp || k.IsCtrl('p'):
+		l.Move(-1, n)
+	case k.Type == KeyDown || k.IsCtrl('n'):
+		l.Move(1, n)
+	case k.Type == KeyPgUp:
+		l.Move(-page, n)
+	case k.Type == KeyPgDn:
+		l.Move(page, n)
+	case k.Type == KeyHome:
+		l.Move(-n, n)
+	case k.Type == KeyEnd:
+		l.Move(n, n)
+	default:
+		return false
+	}
+	return true
+}
+
+// Window returns the range of the n items shown in height rows, scrolling
+// as needed to keep the cursor visible
+func (l *List) Window(n, height int) (int, int) {
+	l.Clamp(n)
+	if height <= 0 {
+		return 0, 0
+	}
+
+	if l.Cursor < l.Offset {
+		l.Offset = l.Cursor
+	}
+	if l.Cursor >= l.Offset+height {
+		l.Offset = l.Cursor - height + 1
+	}
+	if l.Offset > n-height {
+		l.Offset = n - height
+	}
+	if l.Offset < 0 {
+		l.Offset = 0
+	}
+
+	return l.Offset, min(n, l.Offset+height)
+}
+
+// Input is a single-line text input
+type Input struct {
+	text []rune
+	pos  int
+
+	// Mask hides the text behind asterisks
+	Mask bool
+}
+
+// Value returns the text
+func (in *Input) Value() string {
+	return string(in.text)
+}
+
+// Set replaces the text and moves the cursor to its end
+func (in *Input) Set(s string) {
+	in.text = []rune(s)
+	in.pos = len(in.text)
+}
+
+// HandleKey edits the text for editing keys and reports whether the key was
+// handled
+func (in *Input) HandleKey(k Key) bool {
+	switch {
+	case k.Type == KeyRune:
+		in.text = append(in.text[:in.pos], append([]rune{k.Rune}, in.text[in.pos:]...)...)
+		in.pos++
+	case k.Type == KeyBackspace || k.IsCtrl('h'):
+		if in.pos > 0 {
+			in.text = append(in.text[:in.pos-1], in.text[in.pos:]...)
+			in.pos--
+		}
+	case k.Type == KeyDelete || k.IsCtrl('d'):
+		if in.pos < len(in.text) {
+			in.text = append(in.text[:in.pos], in.text[in.pos+1:]...)
+		}
+	case k.Type == KeyLeft || k.IsCtrl('b'):
+		if in.pos > 0 {
+			in.pos--
+		}
+	case k.Type == KeyRight || k.IsCtrl('f'):
+		if in.pos < len(in.text) {
+			in.pos++
+		}
+	case k.IsCtrl('a'):
+		in.pos = 0
+	case k.IsCtrl('e'):
+		in.pos = len(in.text)
+	case k.IsCtrl('u'):
+		in.text = in.text[in.pos:]
+		in.pos = 0
+	case k.IsCtrl('k'):
+		in.text = in.text[:in.pos]
+	case k.IsCtrl('w'):
+		start := in.pos
+		for start > 0 && in.text[start-1] == ' ' {
+			start--
+		}
+		for start > 0 && in.text[start-1] != ' ' {
+			start--
+		}
+		in.text = append(in.text[:start], in.text[in.pos:]...)
+		in.pos = start
+	default:
+		return false
+	}
+	return true
+}
+
+// View renders the text in width cells, with a block cursor when focused
+func (in *Input) View(width int, focused bool) string {
+	if width <= 0 {
+		return ""
+	}
+
+	text := in.text
+	if in.Mask {
+		text = []rune(strings.Repeat("*", len(text)))
+	}
+
+	// Scroll so the cursor stays visible
+	start := 0
+	if in.pos >= width {
+		start = in.pos - width + 1
+	}
+	end := min(len(text), start+width)
+
+	if !focused {
+		return string(text[start:end])
+	}
+
+	before := string(text[start:in.pos])
+	cursor := " "
+	after := ""
+	if in.pos < len(text) {
+		cursor = string(text[in.pos])
+		after = string(text[in.pos+1 : end])
+	}
+	return before + Reverse(cursor) + after
+}
@@ -0,0 +1,111 @@
+package pf
+
+import (
+	"pf/internal/config"
+)
+
+// Config is the pf configuration, as read from config.yaml
+type Config struct {
+	DefaultStore string
+	Stores       map[string]StoreConfig
+
+	// AgeKeyPath is the identity file used when no Identities are configured
+	AgeKeyPath string
+
+	// Identities are the global identity sources
+	Identities []IdentitySource
+}
+
+// StoreConfig describes a configured store
+type StoreConfig struct {
+	Path       string
+	Recipients []string
+
+	// Identities are tried before the global identity sources
+	Identities []IdentitySource
+}
+
+// IdentitySource describes where identities are loaded from. Exactly one of
+// File, Env and Command is set.
+type IdentitySource struct {
+	// File is an identity file. Passphrase-protected files read the
+	// passphrase from PF_AGE_PASSPHRASE, or prompt on the terminal.
+	File string
+
+	// Env is an environment variable holding the identity
+	Env string
+
+	// Command is run with sh -c and prints the identity on stdout
+	Command string
+}
+
+// LoadConfig reads the configuration from path. An empty path resolves the
+// configuration like the pf command: $PF_CONFIG, then ~/.pf/config.yaml.
+// A missing file yields an empty configuration.
+func LoadConfig(path string) (*Config, error) {
+	var cfg *config.Config
+	var err error
+	if path == "" {
+		cfg, err = config.Load()
+	} else {
+		cfg, err = config.LoadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Config{
+		DefaultStore: cfg.DefaultStore,
+		Stores:       make(map[string]StoreConfig, len(cfg.Stores)),
+		AgeKeyPath:   cfg.AgeKeyPath,
+		Identities:   fromSources(cfg.Identities),
+	}
+	for name, storeConfig := range cfg.Stores {
+		result.Stores[name] = StoreConfig{
+			Path:       storeConfig.Path,
+			Recipients: storeConfig.Recipients,
+			Identities: fromSources(storeConfig.Identities),
+		}
+	}
+
+	return result, nil
+}
+
+// IdentitySources returns the identity sources for a store: the store's own
+// sources followed by the global ones, or AgeKeyPath if there are none
+func (c *Config) IdentitySources(storeName string) []IdentitySource {
+	return fromSources(c.internal().IdentitySources(storeName))
+}
+
+func (c *Config) internal() *config.Config {
+	cfg := &config.Config{
+		DefaultStore: c.DefaultStore,
+		Stores:       make(map[string]config.StoreConfig, len(c.Stores)),
+		AgeKeyPath:   c.AgeKeyPath,
+		Identities:   toSources(c.Identities),
+	}
+	for name, storeConfig := range c.Stores {
+		cfg.Stores[name] = config.StoreConfig{
+			Path:       storeConfig.Path,
+			Recipients: storeConfig.Recipients,
+			Identities: toSources(storeConfig.Identities),
+		}
+	}
+	return cfg
+}
+
+func fromSources(sources []config.IdentitySource) []IdentitySource {
+	var result []IdentitySource
+	for _, source := range sources {
+		result = append(result, IdentitySource(source))
+	}
+	return result
+}
+
+func toSources(sources []IdentitySource) []config.IdentitySource {
+	var result []config.IdentitySource
+	for _, source := range sources {
+		result = append(result, config.IdentitySource(source))
+	}
+	return result
+}
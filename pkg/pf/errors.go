@@ -0,0 +1,35 @@
+package pf
+
+import (
+	"errors"
+
+	"pf/internal/store"
+)
+
+var (
+	// ErrStoreNotFound is returned by Open for a store missing from the configuration
+	ErrStoreNotFound = errors.New("store not found")
+
+	// ErrNotFound is returned for keys without an entry in the store
+	ErrNotFound = store.ErrNotFound
+
+	// ErrNoIdentity is returned when no identity could be loaded for decryption
+	ErrNoIdentity = store.ErrNoIdentity
+
+	// ErrDecrypt is returned when no available identity decrypts an entry
+	ErrDecrypt = store.ErrDecrypt
+
+	// ErrVersionOutOfRange is returned for versions an entry does not have
+	ErrVersionOutOfRange = store.ErrVersionOutOfRange
+
+	// ErrCorruptEntry is returned for entry files that cannot be parsed
+	ErrCorruptEntry = store.ErrCorruptEntry
+)
+
+// Error types carrying details, for use with errors.As
+type (
+	NotFoundError     = store.NotFoundError
+	VersionError      = store.VersionError
+	DecryptError      = store.DecryptError
+	CorruptEntryError = store.CorruptEntryError
+)
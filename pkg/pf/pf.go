@@ -0,0 +1,226 @@
+// Package pf reads and writes pf password stores from Go programs.
+//
+// Stores are opened through the pf configuration:
+//
+//	cfg, err := pf.LoadConfig("")
+//	if err != nil {
+//		return err
+//	}
+//	s, err := pf.Open(ctx, cfg, pf.Options{Store: "work", Author: "deploy"})
+//	if err != nil {
+//		return err
+//	}
+//	password, err := s.Get(ctx, "db/production")
+//
+// Errors can be checked with errors.Is against ErrStoreNotFound, ErrNotFound,
+// ErrNoIdentity, ErrDecrypt, ErrVersionOutOfRange and ErrCorruptEntry, or
+// with errors.As for the error types carrying details.
+package pf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"filippo.io/age"
+
+	pfage "pf/internal/age"
+	"pf/internal/keyring"
+	"pf/internal/store"
+)
+
+// Options configure how a store is opened
+type Options struct {
+	// Store is the store name, the default store when empty
+	Store string
+
+	// Identities decrypt entries. When nil, the identity sources configured
+	// for the store are loaded on first use.
+	Identities []age.Identity
+
+	// Author is recorded on the versions written and the audit events of
+	// the store, DefaultAuthor when empty
+	Author string
+}
+
+// DefaultAuthor is recorded when Options.Author is empty. The environment is
+// not consulted, a library user is not necessarily $USER.
+const DefaultAuthor = "library"
+
+// Store is an open password store. It is not safe for concurrent use.
+type Store struct {
+	name       string
+	store      *store.Store
+	sources    []IdentitySource
+	identities []age.Identity
+	loadErr    error
+}
+
+// Version describes a version of an entry, without its password
+type Version struct {
+	Version   int
+	Timestamp time.Time
+	Author    string
+	Message   string
+}
+
+// Open opens a configured store
+func Open(ctx context.Context, cfg *Config, opts Options) (*Store, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	name := opts.Store
+	if name == "" {
+		name = cfg.DefaultStore
+	}
+
+	storeConfig, ok := cfg.Stores[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrStoreNotFound, name)
+	}
+
+	s, err := store.New(storeConfig.Path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store '%s': %w", name, err)
+	}
+	author := opts.Author
+	if author == "" {
+		author = DefaultAuthor
+	}
+	s.SetAuthor(author)
+	if opts.Identities != nil {
+		s.SetIdentities(opts.Identities)
+	}
+
+	return &Store{
+		name:       name,
+		store:      s,
+		sources:    cfg.IdentitySources(name),
+		identities: opts.Identities,
+	}, nil
+}
+
+// Name returns the store name
+func (s *Store) Name() string {
+	return s.name
+}
+
+// Get returns the latest password stored for key
+func (s *Store) Get(ctx context.Context, key string) (string, error) {
+	return s.GetVersion(ctx, key, 0)
+}
+
+// GetVersion returns a version of the password stored for key, 0 for the latest
+func (s *Store) GetVersion(ctx context.Context, key string, version int) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if !s.store.Exists(key) {
+		return "", &NotFoundError{Key: key}
+	}
+	if err := s.loadIdentities(ctx); err != nil {
+		return "", err
+	}
+
+	password, err := s.store.Get(key, version)
+	var decryptErr *DecryptError
+	if errors.As(err, &decryptErr) && s.loadErr != nil {
+		// Sources that failed to load may hold the matching identity
+		decryptErr.Err = errors.Join(decryptErr.Err, s.loadErr)
+	}
+	return password, err
+}
+
+// Put stores password as a new version of key
+func (s *Store) Put(ctx context.Context, key, password, message string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.store.Put(key, password, message)
+}
+
+// List returns the keys in the store, sorted
+func (s *Store) List(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.store.List()
+}
+
+// History returns the versions of key, newest first
+func (s *Store) History(ctx context.Context, key string) ([]Version, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !s.store.Exists(key) {
+		return nil, &NotFoundError{Key: key}
+	}
+
+	versions, err := s.store.GetHistory(key, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]Version, len(versions))
+	for i, v := range versions {
+		history[i] = Version{
+			Version:   v.Version,
+			Timestamp: time.Unix(v.Timestamp, 0),
+			Author:    v.Author,
+			Message:   v.Message,
+		}
+	}
+	return history, nil
+}
+
+func (s *Store) loadIdentities(ctx context.Context) error {
+	if s.identities != nil {
+		return nil
+	}
+
+	identities, err := loadIdentities(ctx, s.sources)
+	if len(identities) == 0 {
+		return err
+	}
+
+	s.identities = identities
+	s.loadErr = err
+	s.store.SetIdentities(identities)
+	return nil
+}
+
+// LoadIdentities loads the identities of the given sources. Sources that fail
+// are skipped as long as at least one identity loads.
+func LoadIdentities(ctx context.Context, sources []IdentitySource) ([]age.Identity, error) {
+	identities, err := loadIdentities(ctx, sources)
+	if len(identities) == 0 {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// loadIdentities also returns the errors of failed sources next to the
+// identities that did load
+func loadIdentities(ctx context.Context, sources []IdentitySource) ([]age.Identity, error) {
+	loaded, err := keyring.New(toSources(sources)).LoadContext(ctx)
+	if len(loaded) == 0 {
+		if err == nil {
+			err = errors.New("no identities configured")
+		}
+		return nil, fmt.Errorf("%w: %w", ErrNoIdentity, err)
+	}
+
+	return pfage.AgeIdentities(loaded), err
+}
+
+// ParseIdentities parses key material in any format accepted in identity
+// files: age keys, plugin identities and OpenSSH private keys
+func ParseIdentities(data []byte) ([]age.Identity, error) {
+	identities, err := pfage.LoadIdentities(data, "identity")
+	if err != nil {
+		return nil, err
+	}
+	return pfage.AgeIdentities(identities), nil
+}
@@ -0,0 +1,159 @@
+package pf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+// openTestStore creates a store encrypted to a new in-memory identity
+func openTestStore(t *testing.T, author string) (*Store, string) {
+	t.Helper()
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	recipients := identity.Recipient().String() + "\n"
+	if err := os.WriteFile(filepath.Join(dir, ".recipients"), []byte(recipients), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{
+		DefaultStore: "test",
+		Stores:       map[string]StoreConfig{"test": {Path: dir}},
+	}
+	s, err := Open(context.Background(), cfg, Options{
+		Identities: []age.Identity{identity},
+		Author:     author,
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return s, dir
+}
+
+func TestPutGetHistory(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t, "deploy")
+
+	if err := s.Put(ctx, "db/prod", "first", "Create"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Put(ctx, "db/prod", "second\nusername: app", "Rotate"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	password, err := s.Get(ctx, "db/prod")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if password != "second\nusername: app" {
+		t.Errorf("Get = %q, want the latest version", password)
+	}
+
+	password, err = s.GetVersion(ctx, "db/prod", 1)
+	if err != nil {
+		t.Fatalf("GetVersion: %v", err)
+	}
+	if password != "first" {
+		t.Errorf("GetVersion(1) = %q, want %q", password, "first")
+	}
+
+	history, err := s.History(ctx, "db/prod")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("History returned %d versions, want 2", len(history))
+	}
+	if history[0].Version != 2 || history[0].Message != "Rotate" || history[0].Author != "deploy" {
+		t.Errorf("History[0] = %+v, want version 2 by deploy with message Rotate", history[0])
+	}
+
+	keys, err := s.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "db/prod" {
+		t.Errorf("List = %q, want [db/prod]", keys)
+	}
+}
+
+func TestDefaultAuthor(t *testing.T) {
+	t.Setenv("USER", "someone-else")
+	ctx := context.Background()
+	s, dir := openTestStore(t, "")
+
+	if err := s.Put(ctx, "api", "s3cret", ""); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := s.Get(ctx, "api"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	history, err := s.History(ctx, "api")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if history[0].Author != DefaultAuthor {
+		t.Errorf("Author = %q, want %q", history[0].Author, DefaultAuthor)
+	}
+
+	log, err := os.ReadFile(filepath.Join(dir, ".audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(log), "someone-else") || !strings.Contains(string(log), "| "+DefaultAuthor+" |") {
+		t.Errorf("audit log is not attributed to %q:\n%s", DefaultAuthor, log)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t, "deploy")
+
+	if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of a missing key: err = %v, want ErrNotFound", err)
+	}
+
+	if err := s.Put(ctx, "api", "s3cret", ""); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := s.GetVersion(ctx, "api", 2); !errors.Is(err, ErrVersionOutOfRange) {
+		t.Errorf("GetVersion(2): err = %v, want ErrVersionOutOfRange", err)
+	}
+
+	cfg := &Config{Stores: map[string]StoreConfig{}}
+	if _, err := Open(ctx, cfg, Options{Store: "nope"}); !errors.Is(err, ErrStoreNotFound) {
+		t.Errorf("Open of an unknown store: err = %v, want ErrStoreNotFound", err)
+	}
+}
+
+func TestWrongIdentity(t *testing.T) {
+	ctx := context.Background()
+	s, dir := openTestStore(t, "deploy")
+	if err := s.Put(ctx, "api", "s3cret", ""); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{DefaultStore: "test", Stores: map[string]StoreConfig{"test": {Path: dir}}}
+	s, err = Open(ctx, cfg, Options{Identities: []age.Identity{other}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := s.Get(ctx, "api"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Get with another identity: err = %v, want ErrDecrypt", err)
+	}
+}